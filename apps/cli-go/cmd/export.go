@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/nkootstra/xpose/internal/protocol"
+	"github.com/nkootstra/xpose/internal/tui"
+	"github.com/nkootstra/xpose/internal/tunnel"
+)
+
+var (
+	exportTTLFlag            int
+	exportSubdomain          string
+	exportDomainFlag         string
+	exportSubdomainStyleFlag string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export captured tunnel traffic",
+}
+
+var exportHarCmd = &cobra.Command{
+	Use:   "har <file> <port...>",
+	Short: "Run tunnels and export the session's traffic log as a HAR 1.2 file on exit",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runExportHar,
+}
+
+func init() {
+	exportHarCmd.Flags().IntVar(&exportTTLFlag, "ttl", protocol.DefaultTTLSeconds, "Tunnel TTL in seconds")
+	exportHarCmd.Flags().StringVar(&exportSubdomain, "subdomain", "", "Custom subdomain (default: random)")
+	exportHarCmd.Flags().StringVar(&exportDomainFlag, "domain", protocol.DefaultPublicDomain, "Public tunnel domain")
+	exportHarCmd.Flags().StringVar(&exportSubdomainStyleFlag, "subdomain-style", "", "Subdomain generator: random, memorable, or custom:<prefix> (default: random, or custom:<prefix> if --subdomain is set)")
+	exportCmd.AddCommand(exportHarCmd)
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExportHar(cmd *cobra.Command, args []string) error {
+	if exportTTLFlag < 1 {
+		return fmt.Errorf("invalid TTL: must be a positive number of seconds")
+	}
+
+	harPath := args[0]
+	portArgs := args[1:]
+
+	tunnelDomain := normalizeDomain(exportDomainFlag)
+	if tunnelDomain == "" {
+		return fmt.Errorf("invalid --domain: pass a hostname like xpose.dev")
+	}
+
+	tunnelTTL := int(math.Min(float64(exportTTLFlag), float64(protocol.MaxTTLSeconds)))
+	baseSubdomain := strings.TrimSpace(exportSubdomain)
+
+	subdomainGen, err := resolveSubdomainGenerator(exportSubdomainStyleFlag, baseSubdomain)
+	if err != nil {
+		return err
+	}
+
+	ports := make([]int, len(portArgs))
+	clients := make([]*tunnel.Client, len(portArgs))
+	for i, arg := range portArgs {
+		port, err := strconv.Atoi(arg)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %q: ports must be between 1 and 65535", arg)
+		}
+		ports[i] = port
+
+		clients[i] = tunnel.NewClient(tunnel.ClientOptions{
+			Subdomain: subdomainFor(subdomainGen, port, len(portArgs)),
+			Port:      port,
+			TTL:       tunnelTTL,
+			Host:      "localhost",
+			Domain:    tunnelDomain,
+		})
+		clients[i].Connect()
+	}
+
+	model := tui.NewModel(clients, ports)
+	p := tea.NewProgram(model)
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+
+	m, ok := finalModel.(tui.Model)
+	if !ok {
+		return fmt.Errorf("unexpected TUI model type")
+	}
+
+	f, err := os.Create(harPath)
+	if err != nil {
+		return fmt.Errorf("failed to create HAR export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := m.ExportHAR(f); err != nil {
+		return fmt.Errorf("failed to export HAR: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "  Traffic exported to %s\n", harPath)
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nkootstra/xpose/internal/metrics"
+)
+
+// metricsShutdownTimeout bounds how long stopMetricsServer waits for
+// in-flight scrapes to finish before exiting anyway.
+const metricsShutdownTimeout = 2 * time.Second
+
+// startMetricsServer starts the optional --metrics HTTP server, serving
+// Prometheus-format metrics plus the /healthz and /readyz checks a
+// cloudflared-style process supervisor would poll. expectedTunnels is the
+// number of tunnels this invocation was asked to open; /readyz only
+// succeeds once every one of them has received an auth-ack.
+func startMetricsServer(addr string, reg *metrics.Registry, expectedTunnels int) *http.Server {
+	mux := http.NewServeMux()
+
+	healthcheck := func(w http.ResponseWriter, r *http.Request) {
+		if reg.ConnectedTunnels() < 1 {
+			http.Error(w, "no tunnel connected", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	mux.HandleFunc("/healthz", healthcheck)
+	// /healthcheck is an alias of /healthz for process supervisors (e.g.
+	// systemd, Docker HEALTHCHECK) that default to that path name.
+	mux.HandleFunc("/healthcheck", healthcheck)
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if reg.AuthenticatedTunnels() < int64(expectedTunnels) {
+			http.Error(w, "not all tunnels authenticated", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if err := reg.Render(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(buf.Bytes())
+	})
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "  Warning: metrics server stopped: %v\n", err)
+		}
+	}()
+
+	return srv
+}
+
+// stopMetricsServer gracefully shuts down a server started by
+// startMetricsServer, if any.
+func stopMetricsServer(srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+	defer cancel()
+	srv.Shutdown(ctx)
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nkootstra/xpose/internal/har"
+	"github.com/nkootstra/xpose/internal/protocol"
+	"github.com/nkootstra/xpose/internal/tunnel"
+)
+
+var replayTarget string
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <file.har>",
+	Short: "Replay captured traffic from a HAR file against a local server",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runReplay,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayTarget, "target", "", "Base URL to replay requests against, e.g. http://localhost:3000 (required)")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	if replayTarget == "" {
+		return fmt.Errorf("--target is required, e.g. --target http://localhost:3000")
+	}
+
+	targetURL, err := url.Parse(replayTarget)
+	if err != nil || targetURL.Hostname() == "" {
+		return fmt.Errorf("invalid --target: %s", replayTarget)
+	}
+
+	host := targetURL.Hostname()
+	port := 80
+	if targetURL.Port() != "" {
+		port, err = strconv.Atoi(targetURL.Port())
+		if err != nil {
+			return fmt.Errorf("invalid --target port: %s", targetURL.Port())
+		}
+	} else if targetURL.Scheme == "https" {
+		port = 443
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read HAR file: %w", err)
+	}
+
+	var log har.Log
+	if err := json.Unmarshal(data, &log); err != nil {
+		return fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+
+	mismatches := 0
+	for i, entry := range log.Log.Entries {
+		result, err := replayEntry(host, port, targetURL.Scheme, entry)
+		if err != nil {
+			fmt.Printf("  [%d] %s %s -> error: %v\n", i, entry.Request.Method, entry.Request.URL, err)
+			mismatches++
+			continue
+		}
+
+		if result.statusMatch && result.bodyMatch {
+			fmt.Printf("  [%d] %s %s -> OK (status %d)\n", i, entry.Request.Method, entry.Request.URL, result.status)
+			continue
+		}
+
+		mismatches++
+		fmt.Printf("  [%d] %s %s -> MISMATCH\n", i, entry.Request.Method, entry.Request.URL)
+		if !result.statusMatch {
+			fmt.Printf("      status: recorded=%d actual=%d\n", entry.Response.Status, result.status)
+		}
+		if !result.bodyMatch {
+			fmt.Printf("      body: recorded=%q\n", truncateForDiff(entry.Response.Content.Text))
+			fmt.Printf("            actual  =%q\n", truncateForDiff(result.body))
+		}
+	}
+
+	fmt.Printf("\n  %d/%d requests matched\n", len(log.Log.Entries)-mismatches, len(log.Log.Entries))
+	if mismatches > 0 {
+		return fmt.Errorf("%d requests did not match their recorded response", mismatches)
+	}
+	return nil
+}
+
+type replayResult struct {
+	status      int
+	body        string
+	statusMatch bool
+	bodyMatch   bool
+}
+
+// replayEntry replays a single HAR entry's request against host:port using
+// the same proxy machinery the tunnel uses for live traffic, and diffs the
+// result against what was recorded.
+func replayEntry(host string, port int, scheme string, entry har.Entry) (*replayResult, error) {
+	path, err := requestPath(entry.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(entry.Request.Headers))
+	for _, h := range entry.Request.Headers {
+		headers[h.Name] = h.Value
+	}
+
+	var body []byte
+	if entry.Request.PostData != nil {
+		body, err = decodeBody(entry.Request.PostData.Text, entry.Request.PostData.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode request body: %w", err)
+		}
+	}
+
+	msg := &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "replay",
+		Method:  entry.Request.Method,
+		Path:    path,
+		Headers: headers,
+		HasBody: len(body) > 0,
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	resp, err := tunnel.ProxyRequest(context.Background(), host, port, msg, reqBody, protocol.DefaultMaxBodySizeBytes, false, nil, scheme, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	actualBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	recordedBody, err := decodeBody(entry.Response.Content.Text, entry.Response.Content.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+	}
+
+	return &replayResult{
+		status:      resp.Status,
+		body:        string(actualBody),
+		statusMatch: resp.Status == entry.Response.Status,
+		bodyMatch:   string(actualBody) == string(recordedBody),
+	}, nil
+}
+
+func requestPath(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid recorded URL %q: %w", rawURL, err)
+	}
+	path := parsed.Path
+	if parsed.RawQuery != "" {
+		path += "?" + parsed.RawQuery
+	}
+	return path, nil
+}
+
+func decodeBody(text, encoding string) ([]byte, error) {
+	if text == "" {
+		return nil, nil
+	}
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+func truncateForDiff(s string) string {
+	const maxLen = 200
+	if len(s) > maxLen {
+		return s[:maxLen] + "..."
+	}
+	return s
+}
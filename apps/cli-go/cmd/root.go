@@ -3,15 +3,22 @@ package cmd
 import (
 	"fmt"
 	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/spf13/cobra"
 
+	"github.com/nkootstra/xpose/internal/discovery"
+	"github.com/nkootstra/xpose/internal/har"
+	"github.com/nkootstra/xpose/internal/metrics"
 	"github.com/nkootstra/xpose/internal/protocol"
 	"github.com/nkootstra/xpose/internal/session"
 	"github.com/nkootstra/xpose/internal/tui"
@@ -21,14 +28,24 @@ import (
 )
 
 var (
-	fromTurbo   bool
-	turboTask   string
-	turboFilter string
-	turboPath   string
-	ttlFlag     int
-	subdomain   string
-	domainFlag  string
-	resumeFlag  bool
+	fromTurbo          bool
+	fromDiscovery      bool
+	turboTask          string
+	turboFilter        string
+	turboPath          string
+	fanoutRoutesFlag   bool
+	routeFlags         []string
+	ttlFlag            int
+	subdomain          string
+	domainFlag         string
+	resumeFlag         bool
+	captureFlag        string
+	metricsFlag        string
+	proxyFlag          string
+	subdomainStyleFlag string
+	customDomainFlag   string
+	tlsModeFlag        string
+	acmeEmailFlag      string
 )
 
 var rootCmd = &cobra.Command{
@@ -41,14 +58,24 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.Flags().BoolVar(&fromTurbo, "from-turbo", false, "Auto-detect ports from Turborepo dev tasks")
+	rootCmd.Flags().BoolVar(&fromDiscovery, "from-discovery", false, "Auto-detect ports from every available source (Turborepo, Nx, pnpm/Yarn workspaces, docker-compose, Procfile, and the live process table), not just Turborepo")
 	rootCmd.Flags().StringVar(&turboTask, "turbo-task", "dev", "Turborepo task to inspect")
 	rootCmd.Flags().StringVar(&turboFilter, "turbo-filter", "", "Optional Turborepo filter")
 	rootCmd.Flags().StringVar(&turboPath, "turbo-path", "", "Path to Turborepo root")
 	rootCmd.Flags().StringVar(&turboPath, "path", "", "Path to Turborepo root (alias)")
+	rootCmd.Flags().BoolVar(&fanoutRoutesFlag, "turbo-routes", false, "Combine --from-turbo ports into one tunnel, routed by path prefix (/<package> per app) instead of one tunnel per port")
+	rootCmd.Flags().StringArrayVar(&routeFlags, "route", nil, "Map a path prefix to a local backend for one fanned-out tunnel, e.g. --route /api=localhost:8080 (repeatable; \"/\" is the default/fallback route)")
 	rootCmd.Flags().IntVar(&ttlFlag, "ttl", protocol.DefaultTTLSeconds, "Tunnel TTL in seconds")
 	rootCmd.Flags().StringVar(&subdomain, "subdomain", "", "Custom subdomain (default: random)")
 	rootCmd.Flags().StringVar(&domainFlag, "domain", protocol.DefaultPublicDomain, "Public tunnel domain")
 	rootCmd.Flags().BoolVarP(&resumeFlag, "resume", "r", false, "Resume the previous tunnel session")
+	rootCmd.Flags().StringVar(&captureFlag, "capture", "", "Record proxied HTTP traffic to a HAR file")
+	rootCmd.Flags().StringVar(&metricsFlag, "metrics", "", "Serve Prometheus metrics and /healthz, /readyz on this address (e.g. :9100)")
+	rootCmd.Flags().StringVar(&proxyFlag, "proxy", "", "Proxy the local target dial through this URL (default: honor HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+	rootCmd.Flags().StringVar(&subdomainStyleFlag, "subdomain-style", "", "Subdomain generator: random, memorable, or custom:<prefix> (default: random, or custom:<prefix> if --subdomain is set)")
+	rootCmd.Flags().StringVar(&customDomainFlag, "custom-domain", "", "Serve the tunnel on this domain instead of the default *.xpose.dev subdomain (requires --tls auto or manual)")
+	rootCmd.Flags().StringVar(&tlsModeFlag, "tls", "", "TLS mode for --custom-domain: auto (xpose obtains a cert via ACME), manual (you supply one out of band), or none (default)")
+	rootCmd.Flags().StringVar(&acmeEmailFlag, "acme-email", "", "Contact address for the ACME account used by --tls auto (optional)")
 }
 
 func normalizeDomain(raw string) string {
@@ -71,10 +98,27 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid TTL: must be a positive number of seconds")
 	}
 
+	tlsMode := strings.TrimSpace(tlsModeFlag)
+	if tlsMode == "" {
+		tlsMode = protocol.TLSModeNone
+	}
+	switch tlsMode {
+	case protocol.TLSModeAuto, protocol.TLSModeManual, protocol.TLSModeNone:
+	default:
+		return fmt.Errorf("invalid --tls: must be auto, manual, or none, got %q", tlsModeFlag)
+	}
+	customDomain := normalizeDomain(customDomainFlag)
+	if customDomain == "" && tlsMode != protocol.TLSModeNone {
+		return fmt.Errorf("--tls requires --custom-domain")
+	}
+	if customDomain != "" && tlsMode == protocol.TLSModeNone {
+		return fmt.Errorf("--custom-domain requires --tls auto or manual")
+	}
+
 	// --- Resume mode ---
 	if resumeFlag {
-		if len(args) > 0 || fromTurbo {
-			return fmt.Errorf("cannot use --resume with port arguments or --from-turbo")
+		if len(args) > 0 || fromTurbo || fromDiscovery {
+			return fmt.Errorf("cannot use --resume with port arguments, --from-turbo, or --from-discovery")
 		}
 
 		prev, err := session.Load()
@@ -88,14 +132,51 @@ func run(cmd *cobra.Command, args []string) error {
 		return runTunnels(cmd, prev.Tunnels)
 	}
 
-	// --- Normal mode: resolve ports ---
+	if fromTurbo && fromDiscovery {
+		return fmt.Errorf("cannot use --from-turbo with --from-discovery (--from-discovery already includes Turborepo)")
+	}
+	if fanoutRoutesFlag && !fromTurbo && !fromDiscovery {
+		return fmt.Errorf("--turbo-routes requires --from-turbo or --from-discovery")
+	}
+	if len(routeFlags) > 0 && fanoutRoutesFlag {
+		return fmt.Errorf("cannot use --route with --turbo-routes")
+	}
+
+	// --- Normal mode: resolve ports/targets ---
+	// Each positional arg is parsed with turbo.ParseTarget, so besides a bare
+	// port ("3000") a user can pass "localhost:3030", a remote host
+	// ("10.2.3.5:3030"), or an explicit scheme ("https://foo",
+	// "https+insecure://10.2.3.4" for a self-signed dev cert). Plain-port
+	// args land in ports same as before; anything with a non-default
+	// scheme/host/insecure flag becomes a richTarget instead.
 	ports := make(map[int]struct{})
+	var richTargets []turbo.Target
+	seenTargets := make(map[string]bool)
 	for _, arg := range args {
-		port, err := strconv.Atoi(arg)
-		if err != nil || port < 1 || port > 65535 {
-			return fmt.Errorf("invalid port %q: ports must be between 1 and 65535", arg)
+		target, err := turbo.ParseTarget(arg)
+		if err != nil {
+			return fmt.Errorf("invalid target %q: %w", arg, err)
+		}
+		if target.Scheme == "http" && target.Host == "127.0.0.1" && !target.InsecureSkipVerify {
+			ports[target.Port] = struct{}{}
+			continue
 		}
-		ports[port] = struct{}{}
+		key := fmt.Sprintf("%s://%s:%d/%v", target.Scheme, target.Host, target.Port, target.InsecureSkipVerify)
+		if seenTargets[key] {
+			continue
+		}
+		seenTargets[key] = true
+		richTargets = append(richTargets, target)
+	}
+
+	var fanoutRoutes []tunnel.Route
+
+	if len(routeFlags) > 0 {
+		manualRoutes, err := parseRouteFlags(routeFlags)
+		if err != nil {
+			return err
+		}
+		fanoutRoutes = manualRoutes
 	}
 
 	// Turborepo port discovery
@@ -139,14 +220,88 @@ func run(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Discovered from Turborepo (%s): %s\n", task, strings.Join(entries, ", "))
 		}
 
-		for _, entry := range discovered {
-			ports[entry.Port] = struct{}{}
+		if fanoutRoutesFlag {
+			if len(discovered) == 0 {
+				return fmt.Errorf("--turbo-routes: no ports detected from Turborepo task %q", task)
+			}
+			fanoutRoutes = routesFromDiscoveredPorts(discovered)
+		} else {
+			for _, entry := range discovered {
+				ports[entry.Port] = struct{}{}
+			}
 		}
 	}
 
-	if len(ports) == 0 {
+	// Multi-source discovery: Turborepo, Nx, pnpm/Yarn workspaces,
+	// docker-compose, Procfile, and the live process table, merged and
+	// deduplicated by discovery.Discover.
+	if fromDiscovery {
+		task := strings.TrimSpace(turboTask)
+		if task == "" {
+			task = "dev"
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+
+		discoveryCwd := cwd
+		if turboPath != "" {
+			discoveryCwd = filepath.Join(cwd, turboPath)
+		}
+
+		info, err := os.Stat(discoveryCwd)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("invalid --path: directory does not exist: %s", discoveryCwd)
+		}
+
+		sources := []discovery.Source{
+			discovery.NewTurboSource(discoveryCwd, task, turboFilter),
+			discovery.NewNxSource(discoveryCwd),
+			discovery.NewWorkspaceSource(discoveryCwd),
+			discovery.NewComposeSource(filepath.Join(discoveryCwd, "docker-compose.yml")),
+			discovery.NewProcfileSource(filepath.Join(discoveryCwd, "Procfile")),
+			discovery.NewProcessSource(),
+		}
+
+		candidates, err := discovery.Discover(cmd.Context(), sources)
+		if err != nil {
+			return fmt.Errorf("discovery failed: %w", err)
+		}
+
+		if len(candidates) == 0 {
+			fmt.Fprintln(os.Stderr, "  Error: No ports detected from discovery.")
+		} else {
+			entries := make([]string, len(candidates))
+			for i, c := range candidates {
+				label := c.PackageName
+				if label == "" {
+					label = c.Reason
+				}
+				entries[i] = fmt.Sprintf("%d [%s]", c.Port, label)
+			}
+			fmt.Printf("  Discovered: %s\n", strings.Join(entries, ", "))
+		}
+
+		if fanoutRoutesFlag {
+			if len(candidates) == 0 {
+				return fmt.Errorf("--turbo-routes: no ports detected from discovery")
+			}
+			fanoutRoutes = routesFromDiscoveredPorts(discoveredPortsFromCandidates(candidates))
+		} else {
+			for _, c := range candidates {
+				ports[c.Port] = struct{}{}
+			}
+		}
+	}
+
+	if len(ports) == 0 && len(richTargets) == 0 && len(fanoutRoutes) == 0 {
 		return fmt.Errorf("no ports provided. Pass ports directly (e.g. `xpose 3000 8787`) or use --from-turbo")
 	}
+	if customDomain != "" && (len(ports)+len(richTargets) > 1 || len(fanoutRoutes) > 0) {
+		return fmt.Errorf("--custom-domain only supports a single tunnel; pass one port without --turbo-routes")
+	}
 
 	// Deduplicate
 	resolvedPorts := make([]int, 0, len(ports))
@@ -160,54 +315,273 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid --domain: pass a hostname like xpose.dev")
 	}
 
+	subdomainGen, err := resolveSubdomainGenerator(subdomainStyleFlag, baseSubdomain)
+	if err != nil {
+		return err
+	}
+
+	tunnelCount := len(resolvedPorts) + len(richTargets)
+
 	// Build tunnel entries with generated subdomains
-	entries := make([]session.TunnelEntry, len(resolvedPorts))
-	for i, port := range resolvedPorts {
-		var sub string
-		if baseSubdomain != "" {
-			if len(resolvedPorts) == 1 {
-				sub = protocol.BuildCustomSubdomain(baseSubdomain)
-			} else {
-				sub = protocol.BuildCustomSubdomain(fmt.Sprintf("%s-%d", baseSubdomain, port))
+	entries := make([]session.TunnelEntry, 0, tunnelCount)
+	for _, port := range resolvedPorts {
+		entries = append(entries, session.TunnelEntry{
+			Subdomain:    subdomainFor(subdomainGen, port, tunnelCount),
+			Port:         port,
+			Domain:       tunnelDomain,
+			CustomDomain: customDomain,
+			TLSMode:      tlsMode,
+			AcmeEmail:    acmeEmailFlag,
+		})
+	}
+	for _, target := range richTargets {
+		entries = append(entries, session.TunnelEntry{
+			Subdomain:      subdomainFor(subdomainGen, target.Port, tunnelCount),
+			Port:           target.Port,
+			Domain:         tunnelDomain,
+			CustomDomain:   customDomain,
+			TLSMode:        tlsMode,
+			AcmeEmail:      acmeEmailFlag,
+			TargetHost:     target.Host,
+			TargetScheme:   target.Scheme,
+			TargetInsecure: target.InsecureSkipVerify,
+		})
+	}
+
+	if len(fanoutRoutes) > 0 {
+		routeEntries := make([]session.RouteEntry, len(fanoutRoutes))
+		for i, r := range fanoutRoutes {
+			routeEntries[i] = session.RouteEntry{
+				PathPrefix:  r.PathPrefix,
+				Host:        r.Host,
+				Port:        r.Port,
+				StripPrefix: r.StripPrefix,
 			}
-		} else {
-			sub = protocol.GenerateSubdomainID()
 		}
-		entries[i] = session.TunnelEntry{
-			Subdomain: sub,
-			Port:      port,
+		entries = append(entries, session.TunnelEntry{
+			Subdomain: subdomainForRoutes(subdomainGen),
 			Domain:    tunnelDomain,
-		}
+			Routes:    routeEntries,
+		})
 	}
 
 	return runTunnels(cmd, entries)
 }
 
+// parseRouteFlags parses repeated "--route PATH=HOST:PORT" flag values into
+// path-prefix Routes for a single fanned-out tunnel, mirroring
+// routesFromDiscoveredPorts' StripPrefix convention. PATH must start with
+// "/"; "/" itself maps to the default/fallback route (empty PathPrefix).
+func parseRouteFlags(raw []string) ([]tunnel.Route, error) {
+	routes := make([]tunnel.Route, 0, len(raw))
+	for _, entry := range raw {
+		eq := strings.IndexByte(entry, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid --route %q: expected PATH=HOST:PORT", entry)
+		}
+		path, target := entry[:eq], entry[eq+1:]
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid --route %q: path must start with \"/\"", entry)
+		}
+		host, portStr, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --route %q: target must be HOST:PORT: %w", entry, err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid --route %q: invalid port %q", entry, portStr)
+		}
+
+		prefix := path
+		if prefix == "/" {
+			prefix = ""
+		}
+		routes = append(routes, tunnel.Route{
+			PathPrefix:  prefix,
+			Host:        host,
+			Port:        port,
+			StripPrefix: prefix != "",
+		})
+	}
+	return routes, nil
+}
+
+// routesFromDiscoveredPorts turns Turborepo's discovered {package, port}
+// results into path-prefix Routes for a single fanned-out tunnel: each app
+// is served under /<package>, with the first (lowest port, per
+// DiscoverTurboPorts' sort) also wired up as the default/fallback route so
+// an unmatched path still reaches a local server instead of 502ing.
+func routesFromDiscoveredPorts(discovered []turbo.DiscoveredPort) []tunnel.Route {
+	routes := make([]tunnel.Route, 0, len(discovered)+1)
+	seenPrefixes := make(map[string]bool, len(discovered))
+	for _, d := range discovered {
+		prefix := routePrefixForPackage(d.PackageName)
+		if seenPrefixes[prefix] {
+			// Two packages share a basename (e.g. "@marketing/web" and
+			// "@admin/web"); fall back to the fully-qualified name so the
+			// second one doesn't silently steal the first one's route.
+			prefix = routePrefixForPackage(sanitizePackageName(d.PackageName))
+		}
+		seenPrefixes[prefix] = true
+
+		routes = append(routes, tunnel.Route{
+			PathPrefix:  prefix,
+			Host:        "localhost",
+			Port:        d.Port,
+			StripPrefix: true,
+		})
+	}
+	routes = append(routes, tunnel.Route{
+		PathPrefix: "",
+		Host:       "localhost",
+		Port:       discovered[0].Port,
+	})
+	return routes
+}
+
+// discoveredPortsFromCandidates adapts discovery.Candidate (the merged,
+// multi-source result) to turbo.DiscoveredPort so it can be fanned out into
+// routes with routesFromDiscoveredPorts, which predates the discovery
+// package and still speaks turbo's type.
+func discoveredPortsFromCandidates(candidates []discovery.Candidate) []turbo.DiscoveredPort {
+	discovered := make([]turbo.DiscoveredPort, len(candidates))
+	for i, c := range candidates {
+		discovered[i] = turbo.DiscoveredPort{
+			Port:        c.Port,
+			PackageName: c.PackageName,
+			Directory:   c.Directory,
+			Command:     c.Command,
+			Reason:      c.Reason,
+		}
+	}
+	return discovered
+}
+
+// routePrefixForPackage derives a path prefix from a Turborepo package name
+// (e.g. "@myapp/web" or "apps/web" both become "/web").
+func routePrefixForPackage(name string) string {
+	if idx := strings.LastIndexByte(name, '/'); idx >= 0 {
+		name = name[idx+1:]
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "/"
+	}
+	return "/" + name
+}
+
+// sanitizePackageName turns a full Turborepo package name (which may
+// include a "@scope/" prefix and slashes) into a single path-safe segment,
+// used to disambiguate packages that share a basename.
+func sanitizePackageName(name string) string {
+	name = strings.TrimPrefix(name, "@")
+	return strings.Map(func(r rune) rune {
+		if r == '/' {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
 // runTunnels creates tunnel clients from entries, saves the session, runs the
 // TUI, and prints the resume hint after exit.
 func runTunnels(_ *cobra.Command, entries []session.TunnelEntry) error {
 	tunnelTTL := int(math.Min(float64(ttlFlag), float64(protocol.MaxTTLSeconds)))
 
+	var proxyURL *url.URL
+	if proxyFlag != "" {
+		parsed, err := url.Parse(proxyFlag)
+		if err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid --proxy: %s", proxyFlag)
+		}
+		proxyURL = parsed
+	}
+
+	var recorder *har.Recorder
+	if captureFlag != "" {
+		recorder = har.NewRecorder(captureFlag)
+	}
+
+	var metricsRegistry *metrics.Registry
+	if metricsFlag != "" {
+		metricsRegistry = metrics.NewRegistry()
+	}
+
+	sess := &session.Session{
+		Tunnels:   entries,
+		CreatedAt: time.Now(),
+	}
+
+	var sessionMu sync.Mutex
+	saveSession := func() {
+		sessionMu.Lock()
+		defer sessionMu.Unlock()
+		if err := session.Save(sess); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: failed to save session: %v\n", err)
+		}
+	}
+
 	clients := make([]*tunnel.Client, len(entries))
 	ports := make([]int, len(entries))
 	for i, e := range entries {
-		ports[i] = e.Port
+		idx := i
+		port := e.Port
+		var routes []tunnel.Route
+		if len(e.Routes) > 0 {
+			routes = make([]tunnel.Route, len(e.Routes))
+			for j, r := range e.Routes {
+				routes[j] = tunnel.Route{
+					PathPrefix:  r.PathPrefix,
+					Host:        r.Host,
+					Port:        r.Port,
+					StripPrefix: r.StripPrefix,
+				}
+			}
+			port = routes[0].Port
+		}
+		ports[i] = port
+
+		var target tunnel.Target
+		if e.TargetHost != "" {
+			target = tunnel.Target{
+				Scheme:             e.TargetScheme,
+				Host:               e.TargetHost,
+				Port:               port,
+				InsecureSkipVerify: e.TargetInsecure,
+			}
+		}
+
 		clients[i] = tunnel.NewClient(tunnel.ClientOptions{
-			Subdomain: e.Subdomain,
-			Port:      e.Port,
-			TTL:       tunnelTTL,
-			Host:      "localhost",
-			Domain:    e.Domain,
+			Subdomain:      e.Subdomain,
+			Port:           port,
+			TTL:            tunnelTTL,
+			Host:           "localhost",
+			Domain:         e.Domain,
+			HarRecorder:    recorder,
+			Metrics:        metricsRegistry,
+			ProxyURL:       proxyURL,
+			Routes:         routes,
+			Target:         target,
+			ReconnectToken: e.ReconnectToken,
+			CustomDomain:   e.CustomDomain,
+			TLSMode:        e.TLSMode,
+			AcmeEmail:      e.AcmeEmail,
+			OnReconnectToken: func(token string) {
+				sessionMu.Lock()
+				entries[idx].ReconnectToken = token
+				sessionMu.Unlock()
+				saveSession()
+			},
 		})
 	}
 
 	// Save session so it can be resumed after exit.
-	sess := &session.Session{
-		Tunnels:   entries,
-		CreatedAt: time.Now(),
-	}
-	if err := session.Save(sess); err != nil {
-		fmt.Fprintf(os.Stderr, "  Warning: failed to save session: %v\n", err)
+	saveSession()
+
+	var metricsServer *http.Server
+	if metricsRegistry != nil {
+		metricsServer = startMetricsServer(metricsFlag, metricsRegistry, len(entries))
+		defer stopMetricsServer(metricsServer)
 	}
 
 	// Start all tunnel connections
@@ -223,9 +597,17 @@ func runTunnels(_ *cobra.Command, entries []session.TunnelEntry) error {
 	}
 
 	// Update session timestamp so the resume window starts from exit time.
+	sessionMu.Lock()
 	sess.CreatedAt = time.Now()
-	if err := session.Save(sess); err != nil {
-		fmt.Fprintf(os.Stderr, "  Warning: failed to save session: %v\n", err)
+	sessionMu.Unlock()
+	saveSession()
+
+	if recorder != nil {
+		if err := recorder.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: failed to write HAR capture: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "  Traffic captured to %s\n", captureFlag)
+		}
 	}
 
 	// Print resume hint after the alt screen exits (persists in terminal).
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nkootstra/xpose/internal/protocol"
+)
+
+// resolveSubdomainGenerator parses --subdomain-style into a
+// protocol.SubdomainGenerator. An empty style preserves the legacy
+// --subdomain behavior: a random subdomain if customPrefix is blank, or a
+// sanitized customPrefix-derived one otherwise.
+func resolveSubdomainGenerator(style, customPrefix string) (protocol.SubdomainGenerator, error) {
+	switch {
+	case style == "":
+		if customPrefix == "" {
+			return protocol.RandomGenerator{}, nil
+		}
+		return protocol.CustomGenerator{Prefix: customPrefix}, nil
+	case style == "random":
+		return protocol.RandomGenerator{}, nil
+	case style == "memorable":
+		return protocol.WordListGenerator{}, nil
+	case strings.HasPrefix(style, "custom:"):
+		return protocol.CustomGenerator{Prefix: strings.TrimPrefix(style, "custom:")}, nil
+	default:
+		return nil, fmt.Errorf("invalid --subdomain-style %q: expected random, memorable, or custom:<prefix>", style)
+	}
+}
+
+// subdomainFor generates one tunnel's subdomain. For a CustomGenerator with
+// more than one port in play, it folds the port number into the prefix so
+// multiple tunnels sharing a --subdomain/--subdomain-style=custom:<prefix>
+// don't race for the same DNS name (e.g. "myapp-3000-..." vs
+// "myapp-8787-...").
+func subdomainFor(gen protocol.SubdomainGenerator, port, portCount int) string {
+	if custom, ok := gen.(protocol.CustomGenerator); ok && portCount > 1 {
+		return protocol.CustomGenerator{Prefix: fmt.Sprintf("%s-%d", custom.Prefix, port)}.Generate()
+	}
+	return gen.Generate()
+}
+
+// subdomainForRoutes generates the subdomain for a single tunnel fanning out
+// to several local backends via Routes, where there's no one port to fold
+// into a CustomGenerator's prefix the way subdomainFor does.
+func subdomainForRoutes(gen protocol.SubdomainGenerator) string {
+	if custom, ok := gen.(protocol.CustomGenerator); ok {
+		return protocol.CustomGenerator{Prefix: custom.Prefix + "-routes"}.Generate()
+	}
+	return gen.Generate()
+}
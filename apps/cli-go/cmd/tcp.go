@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/nkootstra/xpose/internal/protocol"
+	"github.com/nkootstra/xpose/internal/tui"
+	"github.com/nkootstra/xpose/internal/tunnel"
+)
+
+var (
+	tcpTTLFlag            int
+	tcpSubdomain          string
+	tcpDomainFlag         string
+	tcpSubdomainStyleFlag string
+	tcpSocksFlag          bool
+)
+
+var tcpCmd = &cobra.Command{
+	Use:   "tcp <port>",
+	Short: "Expose a local raw TCP server to the internet via Cloudflare",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTCP,
+}
+
+func init() {
+	tcpCmd.Flags().IntVar(&tcpTTLFlag, "ttl", protocol.DefaultTTLSeconds, "Tunnel TTL in seconds")
+	tcpCmd.Flags().StringVar(&tcpSubdomain, "subdomain", "", "Custom subdomain (default: random)")
+	tcpCmd.Flags().StringVar(&tcpDomainFlag, "domain", protocol.DefaultPublicDomain, "Public tunnel domain")
+	tcpCmd.Flags().StringVar(&tcpSubdomainStyleFlag, "subdomain-style", "", "Subdomain generator: random, memorable, or custom:<prefix> (default: random, or custom:<prefix> if --subdomain is set)")
+	tcpCmd.Flags().BoolVar(&tcpSocksFlag, "socks", false, "Run an in-process SOCKS5 server instead of relaying directly to <port>, so remote clients can reach any host your machine can")
+	rootCmd.AddCommand(tcpCmd)
+}
+
+func runTCP(cmd *cobra.Command, args []string) error {
+	if tcpTTLFlag < 1 {
+		return fmt.Errorf("invalid TTL: must be a positive number of seconds")
+	}
+
+	port, err := strconv.Atoi(args[0])
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q: ports must be between 1 and 65535", args[0])
+	}
+
+	tunnelDomain := normalizeDomain(tcpDomainFlag)
+	if tunnelDomain == "" {
+		return fmt.Errorf("invalid --domain: pass a hostname like xpose.dev")
+	}
+
+	baseSubdomain := strings.TrimSpace(tcpSubdomain)
+	subdomainGen, err := resolveSubdomainGenerator(tcpSubdomainStyleFlag, baseSubdomain)
+	if err != nil {
+		return err
+	}
+	sub := subdomainFor(subdomainGen, port, 1)
+
+	tunnelTTL := int(math.Min(float64(tcpTTLFlag), float64(protocol.MaxTTLSeconds)))
+
+	mode := "tcp"
+	if tcpSocksFlag {
+		mode = "socks"
+	}
+
+	client := tunnel.NewClient(tunnel.ClientOptions{
+		Subdomain: sub,
+		Port:      port,
+		TTL:       tunnelTTL,
+		Host:      "localhost",
+		Domain:    tunnelDomain,
+		Mode:      mode,
+	})
+	client.Connect()
+
+	model := tui.NewModel([]*tunnel.Client{client}, []int{port})
+	p := tea.NewProgram(model)
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package acme
+
+import (
+	"strings"
+	"sync"
+)
+
+// ChallengeResponderPath is the path prefix tunnel.Client matches to
+// intercept an http-01 validation request before it reaches the local
+// backend.
+const ChallengeResponderPath = "/.well-known/acme-challenge/"
+
+// ChallengeResponder holds the key authorizations currently being served
+// for in-flight http-01 challenges. Manager publishes one here for the
+// duration of each authorization; tunnel.Client reads it to answer the
+// CA's validation request directly, since the xpose server and the local
+// backend have no reason to know about the challenge at all.
+type ChallengeResponder struct {
+	mu    sync.RWMutex
+	byTok map[string]string // token -> key authorization
+}
+
+// NewChallengeResponder returns an empty responder, ready to have tokens
+// published into it as a Manager works through authorizations.
+func NewChallengeResponder() *ChallengeResponder {
+	return &ChallengeResponder{byTok: make(map[string]string)}
+}
+
+// Publish makes keyAuth the answer for token until Remove is called.
+func (r *ChallengeResponder) Publish(token, keyAuth string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byTok[token] = keyAuth
+}
+
+// Remove stops serving token, once its authorization has resolved.
+func (r *ChallengeResponder) Remove(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byTok, token)
+}
+
+// Respond returns the key authorization for path, if path is an
+// acme-challenge request this responder currently has an answer for.
+func (r *ChallengeResponder) Respond(path string) (keyAuth string, ok bool) {
+	token, found := strings.CutPrefix(path, ChallengeResponderPath)
+	if !found {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keyAuth, ok = r.byTok[token]
+	return keyAuth, ok
+}
@@ -0,0 +1,389 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Client drives the ACME protocol against a single CA directory on behalf
+// of one account key. It holds no certificate state itself (see Order and
+// Manager for that); it's the thin signed-request layer everything else is
+// built on.
+type Client struct {
+	httpClient *http.Client
+	directory  *Directory
+	accountKey *ecdsa.PrivateKey
+	accountURL string
+
+	mu     sync.Mutex
+	nonces []string // pooled Replay-Nonce values, most recently received last
+}
+
+// NewClient fetches directoryURL's directory and returns a Client ready to
+// register an account against it. accountKey is the account's own key,
+// generated or loaded by the caller (see storage.go) so it can persist
+// across runs instead of re-registering every time.
+func NewClient(ctx context.Context, httpClient *http.Client, directoryURL string, accountKey *ecdsa.PrivateKey) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	dir, err := fetchDirectory(ctx, httpClient, directoryURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		httpClient: httpClient,
+		directory:  dir,
+		accountKey: accountKey,
+	}, nil
+}
+
+// GenerateAccountKey creates a fresh ECDSA P-256 account key, the only key
+// type this package's JWS signing supports.
+func GenerateAccountKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// nonce returns a usable Replay-Nonce, either one pooled from a prior
+// response or a freshly fetched one. The CA hands out a nonce with every
+// response so a well-behaved client rarely needs a dedicated newNonce
+// round-trip, but the pool can run dry (e.g. the very first request).
+func (c *Client) nonce(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if n := len(c.nonces); n > 0 {
+		next := c.nonces[n-1]
+		c.nonces = c.nonces[:n-1]
+		c.mu.Unlock()
+		return next, nil
+	}
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.directory.NewNonce, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch acme nonce: %w", err)
+	}
+	defer resp.Body.Close()
+
+	n := resp.Header.Get("Replay-Nonce")
+	if n == "" {
+		return "", fmt.Errorf("fetch acme nonce: server did not return Replay-Nonce")
+	}
+	return n, nil
+}
+
+func (c *Client) stashNonce(resp *http.Response) {
+	if n := resp.Header.Get("Replay-Nonce"); n != "" {
+		c.mu.Lock()
+		c.nonces = append(c.nonces, n)
+		c.mu.Unlock()
+	}
+}
+
+// acmeError is the application/problem+json body the CA returns on a
+// non-2xx response, per RFC 8555 §6.7.
+type acmeError struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+	Status int    `json:"status"`
+}
+
+func (e *acmeError) Error() string {
+	return fmt.Sprintf("acme: %s (%s)", e.Detail, e.Type)
+}
+
+// post signs payload (nil for a POST-as-GET) with the account key and posts
+// it to url, retrying once on badNonce since the CA can reject a pooled
+// nonce it has since expired. out, if non-nil, receives the decoded JSON
+// response body. Returns the raw *http.Response (body already drained) so
+// callers can read location/link headers.
+func (c *Client) post(ctx context.Context, url string, payload []byte, out any) (*http.Response, error) {
+	resp, raw, err := c.postRaw(ctx, url, payload)
+	if err != nil {
+		return resp, err
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return resp, fmt.Errorf("decode acme response from %s: %w", url, err)
+		}
+	}
+	return resp, nil
+}
+
+// postRaw is post without JSON decoding, for endpoints like certificate
+// download whose response body isn't JSON.
+func (c *Client) postRaw(ctx context.Context, url string, payload []byte) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		n, err := c.nonce(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		body, err := signJWS(c.accountKey, c.accountURL, n, url, payload)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("acme request to %s: %w", url, err)
+		}
+		c.stashNonce(resp)
+
+		raw, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if resp.StatusCode >= 400 {
+			var probErr acmeError
+			if json.Unmarshal(raw, &probErr) == nil && probErr.Type != "" {
+				if probErr.Type == "urn:ietf:params:acme:error:badNonce" && attempt == 0 {
+					continue // the pooled nonce was stale; retry once with a fresh one
+				}
+				return resp, raw, &probErr
+			}
+			return resp, raw, fmt.Errorf("acme request to %s: unexpected status %d", url, resp.StatusCode)
+		}
+
+		return resp, raw, nil
+	}
+}
+
+// account is the wire representation of an ACME account object.
+type account struct {
+	Status  string   `json:"status"`
+	Contact []string `json:"contact,omitempty"`
+}
+
+// Register creates (or, if one already exists for this key, fetches) the
+// account, setting c.accountURL for every subsequent signed request. email
+// may be empty; the CA accepts accounts with no contact info.
+func (c *Client) Register(ctx context.Context, email string) error {
+	payload := struct {
+		TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+		Contact              []string `json:"contact,omitempty"`
+		OnlyReturnExisting   bool     `json:"onlyReturnExisting,omitempty"`
+	}{
+		TermsOfServiceAgreed: true,
+	}
+	if email != "" {
+		payload.Contact = []string{"mailto:" + email}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var acc account
+	resp, err := c.post(ctx, c.directory.NewAccount, body, &acc)
+	if err != nil {
+		return err
+	}
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return fmt.Errorf("acme new-account: server did not return an account URL")
+	}
+	c.accountURL = loc
+	return nil
+}
+
+// SetAccountURL restores a previously-registered account URL, so a loaded
+// account key doesn't have to re-register (new-account is idempotent per
+// key, but skipping it saves a round-trip on every renewal).
+func (c *Client) SetAccountURL(url string) {
+	c.accountURL = url
+}
+
+// AccountURL returns the account URL set by Register or SetAccountURL, for
+// the caller to persist alongside the account key.
+func (c *Client) AccountURL() string {
+	return c.accountURL
+}
+
+// identifier is an ACME order/authorization identifier; xpose only ever
+// requests "dns" identifiers.
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Order tracks one certificate order through its authorization, challenge,
+// and finalization lifecycle.
+type Order struct {
+	URL            string
+	FinalizeURL    string
+	CertificateURL string
+	Status         string
+	AuthzURLs      []string
+}
+
+type orderResponse struct {
+	Status         string       `json:"status"`
+	Identifiers    []identifier `json:"identifiers"`
+	Authorizations []string     `json:"authorizations"`
+	Finalize       string       `json:"finalize"`
+	Certificate    string       `json:"certificate,omitempty"`
+}
+
+// NewOrder requests a certificate order for domain.
+func (c *Client) NewOrder(ctx context.Context, domain string) (*Order, error) {
+	payload := struct {
+		Identifiers []identifier `json:"identifiers"`
+	}{
+		Identifiers: []identifier{{Type: "dns", Value: domain}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var ord orderResponse
+	resp, err := c.post(ctx, c.directory.NewOrder, body, &ord)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		URL:         resp.Header.Get("Location"),
+		FinalizeURL: ord.Finalize,
+		Status:      ord.Status,
+		AuthzURLs:   ord.Authorizations,
+	}, nil
+}
+
+// challenge is one authorization's offered validation method.
+type challenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+type authorizationResponse struct {
+	Status     string      `json:"status"`
+	Challenges []challenge `json:"challenges"`
+}
+
+// HTTP01Challenge fetches authzURL and returns its http-01 challenge, the
+// only challenge type this package implements.
+func (c *Client) HTTP01Challenge(ctx context.Context, authzURL string) (token, challengeURL string, err error) {
+	var authz authorizationResponse
+	if _, err := c.post(ctx, authzURL, nil, &authz); err != nil {
+		return "", "", err
+	}
+	for _, ch := range authz.Challenges {
+		if ch.Type == "http-01" {
+			return ch.Token, ch.URL, nil
+		}
+	}
+	return "", "", fmt.Errorf("acme: authorization %s offered no http-01 challenge", authzURL)
+}
+
+// KeyAuthorization returns the key authorization the tunnel client must
+// serve at /.well-known/acme-challenge/<token> for this account key.
+func (c *Client) KeyAuthorization(token string) (string, error) {
+	return keyAuthorization(c.accountKey, token)
+}
+
+// RespondChallenge tells the CA the client has published the key
+// authorization and it's ready to validate. It does not itself poll for
+// the validation result; call WaitAuthorization for that.
+func (c *Client) RespondChallenge(ctx context.Context, challengeURL string) error {
+	_, err := c.post(ctx, challengeURL, []byte("{}"), nil)
+	return err
+}
+
+// pollInterval is how long WaitAuthorization and WaitOrder sleep between
+// polls; overridable in tests so they don't take multiple seconds of wall
+// clock to exercise a few rounds.
+var pollInterval = 2 * time.Second
+
+// WaitAuthorization polls authzURL until the CA reports it valid or
+// invalid, or ctx is canceled.
+func (c *Client) WaitAuthorization(ctx context.Context, authzURL string) error {
+	for {
+		var authz authorizationResponse
+		if _, err := c.post(ctx, authzURL, nil, &authz); err != nil {
+			return err
+		}
+		switch authz.Status {
+		case "valid":
+			return nil
+		case "invalid":
+			return fmt.Errorf("acme: authorization %s failed validation", authzURL)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Finalize submits a CSR for order and polls it until the certificate is
+// issued, returning the PEM-encoded certificate chain.
+func (c *Client) Finalize(ctx context.Context, order *Order, csrDER []byte) ([]byte, error) {
+	payload := struct {
+		CSR string `json:"csr"`
+	}{
+		CSR: base64.RawURLEncoding.EncodeToString(csrDER),
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.post(ctx, order.FinalizeURL, body, nil); err != nil {
+		return nil, err
+	}
+
+	for {
+		var ord orderResponse
+		if _, err := c.post(ctx, order.URL, nil, &ord); err != nil {
+			return nil, err
+		}
+		switch ord.Status {
+		case "valid":
+			return c.downloadCertificate(ctx, ord.Certificate)
+		case "invalid":
+			return nil, fmt.Errorf("acme: order %s failed", order.URL)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// downloadCertificate fetches the PEM certificate chain via POST-as-GET,
+// per RFC 8555 §7.4.2.
+func (c *Client) downloadCertificate(ctx context.Context, certURL string) ([]byte, error) {
+	_, raw, err := c.postRaw(ctx, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
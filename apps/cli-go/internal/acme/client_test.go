@@ -0,0 +1,373 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockACMEServer is a minimal in-memory CA that exercises just the parts of
+// RFC 8555 this package drives: directory, newNonce, newAccount, newOrder,
+// an authorization with one http-01 challenge, and finalize. It issues a
+// nonce per request and rejects any signed request not carrying one it
+// actually handed out, the same way a real CA does.
+type mockACMEServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	nonces       map[string]bool
+	nonceCounter int64
+
+	authzStatus     string // flips to "valid" after validAfterPolls authorization polls following the challenge response
+	authzPolls      int32  // guarded by mu, unlike the other counters which are only ever touched via atomic
+	challengeCalled int32
+	orderPolls      int32
+	validAfterPolls int32 // order (and authz) report "valid" only once this many polls have happened
+	csrPublicKey    any   // captured from the finalize request, so handleCert can return a cert matching the client's CSR key
+}
+
+func newMockACMEServer() *mockACMEServer {
+	s := &mockACMEServer{
+		nonces:          make(map[string]bool),
+		authzStatus:     "pending",
+		validAfterPolls: 2,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/directory", s.handleDirectory)
+	mux.HandleFunc("/new-nonce", s.handleNewNonce)
+	mux.HandleFunc("/new-account", s.handleNewAccount)
+	mux.HandleFunc("/new-order", s.handleNewOrder)
+	mux.HandleFunc("/authz/1", s.handleAuthz)
+	mux.HandleFunc("/challenge/1", s.handleChallenge)
+	mux.HandleFunc("/order/1", s.handleOrder)
+	mux.HandleFunc("/finalize/1", s.handleFinalize)
+	mux.HandleFunc("/cert/1", s.handleCert)
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *mockACMEServer) issueNonce(w http.ResponseWriter) {
+	s.mu.Lock()
+	s.nonceCounter++
+	n := fmt.Sprintf("nonce-%d", s.nonceCounter)
+	s.nonces[n] = true
+	s.mu.Unlock()
+	w.Header().Set("Replay-Nonce", n)
+}
+
+// checkAndConsumeNonce validates the protected header's nonce against the
+// pool of ones this server actually issued, per RFC 8555's anti-replay
+// requirement, and rejects it on reuse.
+func (s *mockACMEServer) checkAndConsumeNonce(r *http.Request, w http.ResponseWriter) bool {
+	var sr signedRequest
+	if json.NewDecoder(r.Body).Decode(&sr) != nil {
+		return false
+	}
+	var hdr jwsProtected
+	if decodeProtected(sr.Protected, &hdr) != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	ok := s.nonces[hdr.Nonce]
+	if ok {
+		delete(s.nonces, hdr.Nonce)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(acmeError{Type: "urn:ietf:params:acme:error:badNonce", Detail: "bad nonce", Status: 400})
+		return false
+	}
+	return true
+}
+
+func (s *mockACMEServer) handleDirectory(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(Directory{
+		NewNonce:   s.URL + "/new-nonce",
+		NewAccount: s.URL + "/new-account",
+		NewOrder:   s.URL + "/new-order",
+	})
+}
+
+func (s *mockACMEServer) handleNewNonce(w http.ResponseWriter, r *http.Request) {
+	s.issueNonce(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *mockACMEServer) handleNewAccount(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAndConsumeNonce(r, w) {
+		return
+	}
+	s.issueNonce(w)
+	w.Header().Set("Location", s.URL+"/account/1")
+	json.NewEncoder(w).Encode(account{Status: "valid"})
+}
+
+func (s *mockACMEServer) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAndConsumeNonce(r, w) {
+		return
+	}
+	s.issueNonce(w)
+	w.Header().Set("Location", s.URL+"/order/1")
+	json.NewEncoder(w).Encode(orderResponse{
+		Status:         "pending",
+		Authorizations: []string{s.URL + "/authz/1"},
+		Finalize:       s.URL + "/finalize/1",
+	})
+}
+
+func (s *mockACMEServer) handleAuthz(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAndConsumeNonce(r, w) {
+		return
+	}
+	s.issueNonce(w)
+
+	s.mu.Lock()
+	// The CA only starts validating once the client has told it the
+	// challenge is ready (handleChallenge); it then takes validAfterPolls
+	// more authorization polls to report "valid", standing in for the
+	// asynchronous validation a real CA performs.
+	if atomic.LoadInt32(&s.challengeCalled) > 0 && s.authzStatus != "valid" {
+		s.authzPolls++
+		if s.authzPolls >= s.validAfterPolls {
+			s.authzStatus = "valid"
+		}
+	}
+	status := s.authzStatus
+	s.mu.Unlock()
+
+	json.NewEncoder(w).Encode(authorizationResponse{
+		Status: status,
+		Challenges: []challenge{
+			{Type: "http-01", URL: s.URL + "/challenge/1", Token: "test-token", Status: "pending"},
+		},
+	})
+}
+
+func (s *mockACMEServer) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAndConsumeNonce(r, w) {
+		return
+	}
+	s.issueNonce(w)
+	atomic.AddInt32(&s.challengeCalled, 1)
+
+	json.NewEncoder(w).Encode(challenge{Type: "http-01", URL: s.URL + "/challenge/1", Token: "test-token", Status: "processing"})
+}
+
+func (s *mockACMEServer) handleOrder(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAndConsumeNonce(r, w) {
+		return
+	}
+	s.issueNonce(w)
+
+	polls := atomic.AddInt32(&s.orderPolls, 1)
+	status := "pending"
+	if polls >= s.validAfterPolls {
+		s.mu.Lock()
+		s.authzStatus = "valid"
+		s.mu.Unlock()
+		status = "valid"
+	}
+
+	json.NewEncoder(w).Encode(orderResponse{
+		Status:      status,
+		Finalize:    s.URL + "/finalize/1",
+		Certificate: s.URL + "/cert/1",
+	})
+}
+
+func (s *mockACMEServer) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	var sr signedRequest
+	if json.NewDecoder(r.Body).Decode(&sr) != nil {
+		http.Error(w, "bad body", http.StatusBadRequest)
+		return
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(sr.Payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var fin struct {
+		CSR string `json:"csr"`
+	}
+	if json.Unmarshal(payload, &fin) != nil {
+		http.Error(w, "bad payload", http.StatusBadRequest)
+		return
+	}
+	csrDER, err := base64.RawURLEncoding.DecodeString(fin.CSR)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.csrPublicKey = csr.PublicKey
+	s.mu.Unlock()
+
+	s.issueNonce(w)
+	json.NewEncoder(w).Encode(orderResponse{Status: "processing"})
+}
+
+func (s *mockACMEServer) handleCert(w http.ResponseWriter, r *http.Request) {
+	if !s.checkAndConsumeNonce(r, w) {
+		return
+	}
+	s.issueNonce(w)
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+
+	s.mu.Lock()
+	pub := s.csrPublicKey
+	s.mu.Unlock()
+
+	certPEM, err := testCertPEM("example.com", pub)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(certPEM)
+}
+
+// decodeProtected base64url-decodes and unmarshals a JWS protected header,
+// mirroring what a real CA's signature verification step would do (this
+// mock skips signature verification itself, since exercising that isn't
+// the point of these tests).
+func decodeProtected(protectedB64 string, out *jwsProtected) error {
+	raw, err := base64.RawURLEncoding.DecodeString(protectedB64)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func TestClient_RegisterFetchesNonceAndSetsAccountURL(t *testing.T) {
+	srv := newMockACMEServer()
+	defer srv.Close()
+
+	key, err := GenerateAccountKey()
+	require.NoError(t, err)
+
+	c, err := NewClient(t.Context(), srv.Client(), srv.URL+"/directory", key)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Register(t.Context(), "dev@example.com"))
+	assert.Equal(t, srv.URL+"/account/1", c.AccountURL())
+}
+
+func TestClient_NewOrderAndWaitAuthorizationPollsUntilValid(t *testing.T) {
+	srv := newMockACMEServer()
+	defer srv.Close()
+
+	key, err := GenerateAccountKey()
+	require.NoError(t, err)
+
+	c, err := NewClient(t.Context(), srv.Client(), srv.URL+"/directory", key)
+	require.NoError(t, err)
+	require.NoError(t, c.Register(t.Context(), ""))
+
+	order, err := c.NewOrder(t.Context(), "example.com")
+	require.NoError(t, err)
+	require.Len(t, order.AuthzURLs, 1)
+
+	token, challengeURL, err := c.HTTP01Challenge(t.Context(), order.AuthzURLs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "test-token", token)
+
+	keyAuth, err := c.KeyAuthorization(token)
+	require.NoError(t, err)
+	assert.Contains(t, keyAuth, token+".")
+
+	require.NoError(t, c.RespondChallenge(t.Context(), challengeURL))
+
+	origInterval := pollInterval
+	pollInterval = 0
+	defer func() { pollInterval = origInterval }()
+
+	require.NoError(t, c.WaitAuthorization(t.Context(), order.AuthzURLs[0]))
+	srv.mu.Lock()
+	polls := srv.authzPolls
+	srv.mu.Unlock()
+	assert.GreaterOrEqual(t, polls, srv.validAfterPolls)
+}
+
+func TestManager_EnsureCertificateIssuesThenReusesCache(t *testing.T) {
+	srv := newMockACMEServer()
+	defer srv.Close()
+
+	origInterval := pollInterval
+	pollInterval = 0
+	defer func() { pollInterval = origInterval }()
+
+	dir := t.TempDir()
+	origOverride := configDirOverride
+	configDirOverride = dir
+	defer func() { configDirOverride = origOverride }()
+
+	m := &Manager{
+		Domain:       "example.com",
+		DirectoryURL: srv.URL + "/directory",
+		Responder:    NewChallengeResponder(),
+		HTTPClient:   srv.Client(),
+	}
+
+	result, err := m.EnsureCertificate(t.Context())
+	require.NoError(t, err)
+	assert.True(t, result.Issued)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&srv.challengeCalled))
+
+	// A second call within the cached cert's validity window must reuse it
+	// rather than driving another order/challenge round.
+	result2, err := m.EnsureCertificate(t.Context())
+	require.NoError(t, err)
+	assert.False(t, result2.Issued)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&srv.challengeCalled))
+}
+
+// testCertPEM self-signs a leaf certificate for cn over pub, the public key
+// extracted from the client's actual CSR, so the result is a valid pair
+// with whatever private key the client generated for this order. A real CA
+// would sign with its own intermediate key instead of self-signing, but
+// nothing under test inspects the issuer chain.
+func testCertPEM(cn string, pub any) ([]byte, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
@@ -0,0 +1,45 @@
+// Package acme implements just enough of RFC 8555 (ACME) to let the xpose
+// client obtain and renew a certificate for a user's custom domain directly
+// from a CA, using the tunnel's own HTTP-01 challenge path rather than
+// handing the account/private key to the xpose server. It intentionally
+// covers only the http-01 flow xpose needs, not the full ACME surface.
+package acme
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Directory is the CA's advertised set of endpoint URLs, fetched once per
+// Client and cached for the life of the process.
+type Directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+	RevokeCert string `json:"revokeCert,omitempty"`
+}
+
+// fetchDirectory retrieves and decodes the CA's directory object.
+func fetchDirectory(ctx context.Context, httpClient *http.Client, directoryURL string) (*Directory, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, directoryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch acme directory: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch acme directory: unexpected status %d", resp.StatusCode)
+	}
+
+	var dir Directory
+	if err := json.NewDecoder(resp.Body).Decode(&dir); err != nil {
+		return nil, fmt.Errorf("decode acme directory: %w", err)
+	}
+	return &dir, nil
+}
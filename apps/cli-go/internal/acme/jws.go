@@ -0,0 +1,140 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// jwk is the JSON Web Key encoding of an ECDSA P-256 public key, the only
+// key type xpose's account keys use.
+type jwk struct {
+	KeyType string `json:"kty"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func publicJWK(key *ecdsa.PublicKey) jwk {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return jwk{
+		KeyType: "EC",
+		Curve:   "P-256",
+		X:       base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		Y:       base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, used both as the account
+// key fingerprint and as the suffix of an http-01 key authorization.
+func thumbprint(key *ecdsa.PublicKey) (string, error) {
+	k := publicJWK(key)
+	// RFC 7638 requires the lexicographically-ordered, unpadded member set.
+	ordered := struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}{k.Curve, k.KeyType, k.X, k.Y}
+	data, err := json.Marshal(ordered)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// jwsProtected is the protected header of a flattened-JSON JWS, as ACME
+// requires it: either kid (an existing account URL) or jwk (for the
+// account's first request, new-account) identifies the signer, never both.
+type jwsProtected struct {
+	Algorithm string `json:"alg"`
+	Nonce     string `json:"nonce"`
+	URL       string `json:"url"`
+	KeyID     string `json:"kid,omitempty"`
+	JWK       *jwk   `json:"jwk,omitempty"`
+}
+
+// signedRequest is the flattened JSON Web Signature body ACME expects on
+// the wire for every authenticated request.
+type signedRequest struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// signJWS builds a flattened-JSON JWS over payload (nil for a POST-as-GET),
+// signed with key and addressed to url. kid is the account URL once known;
+// pass it empty to sign with the account's own jwk instead (only valid for
+// new-account).
+func signJWS(key *ecdsa.PrivateKey, kid, nonce, url string, payload []byte) ([]byte, error) {
+	protected := jwsProtected{
+		Algorithm: "ES256",
+		Nonce:     nonce,
+		URL:       url,
+	}
+	if kid != "" {
+		protected.KeyID = kid
+	} else {
+		k := publicJWK(&key.PublicKey)
+		protected.JWK = &k
+	}
+
+	protectedJSON, err := json.Marshal(protected)
+	if err != nil {
+		return nil, err
+	}
+	protectedB64 := base64.RawURLEncoding.EncodeToString(protectedJSON)
+
+	var payloadB64 string
+	if payload != nil {
+		payloadB64 = base64.RawURLEncoding.EncodeToString(payload)
+	}
+
+	signingInput := protectedB64 + "." + payloadB64
+	sig, err := signES256(key, signingInput)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(signedRequest{
+		Protected: protectedB64,
+		Payload:   payloadB64,
+		Signature: sig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// signES256 signs signingInput with an ECDSA P-256 key and returns the
+// base64url-encoded fixed-width r||s signature JWS expects, rather than the
+// ASN.1 DER encoding crypto/ecdsa's Sign produces by default.
+func signES256(key *ecdsa.PrivateKey, signingInput string) (string, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign jws: %w", err)
+	}
+
+	size := (elliptic.P256().Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// keyAuthorization builds the http-01 key authorization for token, per
+// RFC 8555 §8.3: the token joined to the account key's thumbprint.
+func keyAuthorization(key *ecdsa.PrivateKey, token string) (string, error) {
+	tp, err := thumbprint(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return token + "." + tp, nil
+}
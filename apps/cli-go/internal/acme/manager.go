@@ -0,0 +1,225 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LetsEncryptDirectoryURL is the production Let's Encrypt ACME directory,
+// the default CA when Manager is created with an empty directoryURL.
+const LetsEncryptDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+// renewalFraction is how far through a certificate's validity window
+// Manager waits before renewing: 2/3 of the way from issuance to
+// expiration, matching the request's stated renewal policy.
+const renewalFraction = 2.0 / 3.0
+
+// Manager obtains and renews a single domain's certificate via http-01,
+// persisting both the account key and the issued certificate under the
+// user's config dir so a restart doesn't have to re-issue. It has no
+// knowledge of the tunnel protocol; the caller (tunnel.Client) is
+// responsible for publishing challenge tokens into the Responder it was
+// built with and for deciding what to do with the resulting certificate.
+type Manager struct {
+	Domain       string
+	Email        string
+	DirectoryURL string
+	Responder    *ChallengeResponder
+	HTTPClient   *http.Client
+
+	mu         sync.Mutex
+	acmeClient *Client
+}
+
+// NewManager returns a Manager for domain. responder must be the same
+// ChallengeResponder the tunnel client consults when intercepting
+// /.well-known/acme-challenge/ requests.
+func NewManager(domain, email string, responder *ChallengeResponder) *Manager {
+	return &Manager{
+		Domain:       domain,
+		Email:        email,
+		DirectoryURL: LetsEncryptDirectoryURL,
+		Responder:    responder,
+	}
+}
+
+// CertResult is the outcome of EnsureCertificate.
+type CertResult struct {
+	Certificate tls.Certificate
+	NotAfter    time.Time
+	Issued      bool // true if this call performed a fresh ACME issuance/renewal rather than reusing a cached cert
+	Renewed     bool // true if Issued is true and a prior cert for this domain already existed on disk
+}
+
+// EnsureCertificate returns a valid certificate for m.Domain, reusing a
+// cached one from a previous run if it's not yet within renewalFraction of
+// expiring, otherwise driving the full ACME http-01 flow to get a new one.
+func (m *Manager) EnsureCertificate(ctx context.Context) (*CertResult, error) {
+	sc, err := loadCert(m.Domain)
+	if err != nil {
+		return nil, err
+	}
+	if sc != nil && !needsRenewal(sc.NotAfter) {
+		cert, err := tls.X509KeyPair([]byte(sc.CertPEM), []byte(sc.KeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("parse cached acme certificate: %w", err)
+		}
+		return &CertResult{Certificate: cert, NotAfter: sc.NotAfter, Issued: false}, nil
+	}
+
+	cert, notAfter, certPEM, keyPEM, err := m.issue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveCert(m.Domain, &storedCert{KeyPEM: keyPEM, CertPEM: certPEM, NotAfter: notAfter}); err != nil {
+		return nil, fmt.Errorf("persist acme certificate: %w", err)
+	}
+	return &CertResult{Certificate: cert, NotAfter: notAfter, Issued: true, Renewed: sc != nil}, nil
+}
+
+// needsRenewal reports whether a certificate expiring at notAfter has
+// passed renewalFraction of its lifetime, estimating "issued at" from a
+// standard 90-day Let's Encrypt lifetime when the issuance time isn't
+// otherwise tracked.
+func needsRenewal(notAfter time.Time) bool {
+	const assumedLifetime = 90 * 24 * time.Hour
+	renewAt := notAfter.Add(-time.Duration(float64(assumedLifetime) * (1 - renewalFraction)))
+	return !time.Now().Before(renewAt)
+}
+
+// client lazily creates (or reuses a persisted) ACME account, so the first
+// call to EnsureCertificate pays the directory-fetch and registration cost
+// and every later renewal skips straight to ordering.
+func (m *Manager) client(ctx context.Context) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.acmeClient != nil {
+		return m.acmeClient, nil
+	}
+
+	httpClient := m.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	key, accountURL, err := loadAccount()
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		key, err = GenerateAccountKey()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	directoryURL := m.DirectoryURL
+	if directoryURL == "" {
+		directoryURL = LetsEncryptDirectoryURL
+	}
+	c, err := NewClient(ctx, httpClient, directoryURL, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if accountURL != "" {
+		c.SetAccountURL(accountURL)
+	} else {
+		if err := c.Register(ctx, m.Email); err != nil {
+			return nil, err
+		}
+		if err := saveAccount(key, c.AccountURL()); err != nil {
+			return nil, err
+		}
+	}
+
+	m.acmeClient = c
+	return c, nil
+}
+
+// issue drives a full new-order/authorize/finalize round for m.Domain and
+// returns the parsed certificate plus its PEM and key PEM for persistence.
+func (m *Manager) issue(ctx context.Context) (cert tls.Certificate, notAfter time.Time, certPEM, keyPEM string, err error) {
+	c, err := m.client(ctx)
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+
+	order, err := c.NewOrder(ctx, m.Domain)
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := m.completeAuthorization(ctx, c, authzURL); err != nil {
+			return cert, notAfter, "", "", err
+		}
+	}
+
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.Domain},
+		DNSNames: []string{m.Domain},
+	}, certKey)
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+
+	chainPEM, err := c.Finalize(ctx, order, csrDER)
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(certKey)
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+	keyPEMBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	tlsCert, err := tls.X509KeyPair(chainPEM, keyPEMBytes)
+	if err != nil {
+		return cert, notAfter, "", "", fmt.Errorf("parse issued certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return cert, notAfter, "", "", err
+	}
+
+	return tlsCert, leaf.NotAfter, string(chainPEM), string(keyPEMBytes), nil
+}
+
+// completeAuthorization fetches authzURL's http-01 challenge, publishes the
+// key authorization for the duration of validation, and waits for the CA
+// to confirm it.
+func (m *Manager) completeAuthorization(ctx context.Context, c *Client, authzURL string) error {
+	token, challengeURL, err := c.HTTP01Challenge(ctx, authzURL)
+	if err != nil {
+		return err
+	}
+
+	keyAuth, err := c.KeyAuthorization(token)
+	if err != nil {
+		return err
+	}
+
+	m.Responder.Publish(token, keyAuth)
+	defer m.Responder.Remove(token)
+
+	if err := c.RespondChallenge(ctx, challengeURL); err != nil {
+		return err
+	}
+	return c.WaitAuthorization(ctx, authzURL)
+}
@@ -0,0 +1,145 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// configDirOverride is set during tests to avoid polluting the real config,
+// mirroring the session package's equivalent.
+var configDirOverride string
+
+// configDir returns the acme subdirectory of the xpose config dir, creating
+// it if necessary when create is true.
+func configDir(create bool) (string, error) {
+	var base string
+	if configDirOverride != "" {
+		base = configDirOverride
+	} else {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(dir, "xpose")
+	}
+	dir := filepath.Join(base, "acme")
+	if create {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// accountFileName is shared by every domain; one account key is registered
+// per CA regardless of how many domains it issues certificates for.
+const accountFileName = "account.json"
+
+// storedAccount is the on-disk form of the account key and its CA-assigned
+// URL, so a restart can resume the account instead of registering a new
+// one every run.
+type storedAccount struct {
+	KeyPEM string `json:"keyPem"`
+	URL    string `json:"url"`
+}
+
+// loadAccount reads the persisted account key and URL, returning (nil, "",
+// nil) if none has been saved yet.
+func loadAccount() (*ecdsa.PrivateKey, string, error) {
+	dir, err := configDir(false)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, accountFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	var sa storedAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, "", nil // treat a corrupt file as missing; a fresh account key will be generated
+	}
+	block, _ := pem.Decode([]byte(sa.KeyPEM))
+	if block == nil {
+		return nil, "", nil
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, "", nil
+	}
+	return key, sa.URL, nil
+}
+
+// saveAccount persists key and the CA-assigned account URL.
+func saveAccount(key *ecdsa.PrivateKey, url string) error {
+	dir, err := configDir(true)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	data, err := json.MarshalIndent(storedAccount{KeyPEM: string(keyPEM), URL: url}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, accountFileName), data, 0o600)
+}
+
+// storedCert is the on-disk form of an issued certificate and the key it
+// was issued for, one file per domain.
+type storedCert struct {
+	KeyPEM   string    `json:"keyPem"`
+	CertPEM  string    `json:"certPem"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+func certFileName(domain string) string {
+	return domain + ".json"
+}
+
+// loadCert reads the persisted certificate for domain, returning (nil, nil)
+// if none has been issued yet.
+func loadCert(domain string) (*storedCert, error) {
+	dir, err := configDir(false)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, certFileName(domain)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sc storedCert
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, nil // treat a corrupt file as missing; Manager will re-issue
+	}
+	return &sc, nil
+}
+
+// saveCert persists the certificate and key issued for domain.
+func saveCert(domain string, sc *storedCert) error {
+	dir, err := configDir(true)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, certFileName(domain)), data, 0o600)
+}
@@ -0,0 +1,65 @@
+// Package discovery finds local dev servers worth tunneling, by checking
+// several independent sources (a monorepo task runner, a workspace
+// manifest, a compose file, a Procfile, the live process table) and
+// merging whatever they find into one deduplicated list.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Candidate is a local dev server found by a discovery Source: a port some
+// process is listening on, plus whatever context the source could attach
+// (the monorepo package/service it belongs to, the directory it runs
+// from, the command line that started it, and why the port was picked).
+type Candidate struct {
+	Port        int
+	PackageName string
+	Directory   string
+	Command     string
+	Reason      string // e.g. "explicit", "default", "compose", "compose-expose", "listening"
+}
+
+// Source discovers local dev servers using one mechanism (a monorepo task
+// runner, a workspace manifest, a compose file, or the live process
+// table).
+type Source interface {
+	Discover(ctx context.Context) ([]Candidate, error)
+}
+
+// Discover runs every source in order and merges their candidates,
+// deduplicating by port with first-wins semantics (mirroring
+// turbo.DiscoverTurboPorts' existing dedup behavior), so an earlier, more
+// specific source (e.g. an explicit monorepo task) takes priority over a
+// later, more general one (e.g. the live process scan). A source that
+// errors is skipped rather than aborting the whole discovery, since in
+// practice "nx isn't installed" just means that source has nothing to
+// contribute, not that discovery itself failed; Discover only returns an
+// error if every source does.
+func Discover(ctx context.Context, sources []Source) ([]Candidate, error) {
+	seen := make(map[int]struct{})
+	var candidates []Candidate
+	var errs []error
+
+	for _, src := range sources {
+		found, err := src.Discover(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, c := range found {
+			if _, exists := seen[c.Port]; exists {
+				continue
+			}
+			seen[c.Port] = struct{}{}
+			candidates = append(candidates, c)
+		}
+	}
+
+	if len(candidates) == 0 && len(errs) > 0 && len(errs) == len(sources) {
+		return nil, fmt.Errorf("all discovery sources failed: %w", errors.Join(errs...))
+	}
+	return candidates, nil
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSource struct {
+	candidates []Candidate
+	err        error
+}
+
+func (f *fakeSource) Discover(_ context.Context) ([]Candidate, error) {
+	return f.candidates, f.err
+}
+
+func TestDiscover_MergesInOrder(t *testing.T) {
+	sources := []Source{
+		&fakeSource{candidates: []Candidate{{Port: 3000, PackageName: "web"}}},
+		&fakeSource{candidates: []Candidate{{Port: 5173, PackageName: "docs"}}},
+	}
+
+	got, err := Discover(context.Background(), sources)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "web", got[0].PackageName)
+	assert.Equal(t, "docs", got[1].PackageName)
+}
+
+func TestDiscover_DeduplicatesByPortFirstWins(t *testing.T) {
+	sources := []Source{
+		&fakeSource{candidates: []Candidate{{Port: 3000, PackageName: "explicit-web"}}},
+		&fakeSource{candidates: []Candidate{{Port: 3000, PackageName: "listening-web"}}},
+	}
+
+	got, err := Discover(context.Background(), sources)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "explicit-web", got[0].PackageName)
+}
+
+func TestDiscover_SkipsFailingSourceWhenOthersSucceed(t *testing.T) {
+	sources := []Source{
+		&fakeSource{err: errors.New("nx: command not found")},
+		&fakeSource{candidates: []Candidate{{Port: 3000, PackageName: "web"}}},
+	}
+
+	got, err := Discover(context.Background(), sources)
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "web", got[0].PackageName)
+}
+
+func TestDiscover_ErrorsWhenEverySourceFails(t *testing.T) {
+	sources := []Source{
+		&fakeSource{err: errors.New("nx: command not found")},
+		&fakeSource{err: errors.New("no docker-compose.yml")},
+	}
+
+	got, err := Discover(context.Background(), sources)
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func TestDiscover_NoSourcesReturnsEmpty(t *testing.T) {
+	got, err := Discover(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeSource discovers dev-server ports from a docker-compose file's
+// "ports:" (host:container mappings published to the host) and "expose:"
+// (container-only, not reachable from the host) entries.
+type ComposeSource struct {
+	Path string
+	FS   FileReader
+}
+
+// NewComposeSource creates a ComposeSource reading the compose file at
+// path (e.g. "docker-compose.yml"), using the OS filesystem.
+func NewComposeSource(path string) *ComposeSource {
+	return &ComposeSource{Path: path, FS: osFileReader{}}
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Ports  []yamlScalar `yaml:"ports"`
+	Expose []yamlScalar `yaml:"expose"`
+}
+
+// yamlScalar captures a compose port entry as its literal text regardless
+// of whether YAML parsed it as a string ("3000:3000") or an int (3000),
+// since either is valid compose syntax.
+type yamlScalar string
+
+func (s *yamlScalar) UnmarshalYAML(value *yaml.Node) error {
+	*s = yamlScalar(value.Value)
+	return nil
+}
+
+func (s *ComposeSource) Discover(ctx context.Context) ([]Candidate, error) {
+	fs := s.FS
+	if fs == nil {
+		fs = osFileReader{}
+	}
+
+	data, err := fs.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.Path, err)
+	}
+
+	var file composeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+
+	dir := filepath.Dir(s.Path)
+
+	var candidates []Candidate
+	for name, svc := range file.Services {
+		for _, p := range svc.Ports {
+			if port, ok := hostPortFromComposePortMapping(string(p)); ok {
+				candidates = append(candidates, Candidate{
+					Port:        port,
+					PackageName: name,
+					Directory:   dir,
+					Reason:      "compose",
+				})
+			}
+		}
+		for _, p := range svc.Expose {
+			if port, err := strconv.Atoi(strings.TrimSpace(string(p))); err == nil {
+				candidates = append(candidates, Candidate{
+					Port:        port,
+					PackageName: name,
+					Directory:   dir,
+					Reason:      "compose-expose",
+				})
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// hostPortFromComposePortMapping extracts the host-side port from a
+// compose "ports:" entry, which may be a bare container port ("3000"), a
+// "host:container" mapping, or a "host:container/proto" mapping with an
+// optional bind address ("127.0.0.1:3000:3000"). The host port (the
+// second-to-last colon-separated field, or the only field if there's no
+// colon) is what's reachable from outside the container, which is what a
+// tunnel needs to proxy to.
+func hostPortFromComposePortMapping(mapping string) (int, bool) {
+	mapping = strings.TrimSpace(mapping)
+	if mapping == "" {
+		return 0, false
+	}
+	if idx := strings.IndexByte(mapping, '/'); idx >= 0 {
+		mapping = mapping[:idx]
+	}
+
+	parts := strings.Split(mapping, ":")
+	portStr := parts[0]
+	if len(parts) > 1 {
+		portStr = parts[len(parts)-2]
+	}
+
+	port, err := strconv.Atoi(strings.TrimSpace(portStr))
+	if err != nil {
+		return 0, false
+	}
+	return port, true
+}
@@ -0,0 +1,82 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFileReader is an in-memory FileReader keyed by exact path.
+type fakeFileReader struct {
+	files map[string]string
+}
+
+func (f *fakeFileReader) ReadFile(path string) ([]byte, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("no such file: %s", path)
+	}
+	return []byte(content), nil
+}
+
+func TestComposeSource_Discover(t *testing.T) {
+	fs := &fakeFileReader{files: map[string]string{
+		"/repo/docker-compose.yml": `
+services:
+  web:
+    ports:
+      - "3000:3000"
+  api:
+    ports:
+      - "127.0.0.1:8080:8080/tcp"
+  worker:
+    expose:
+      - "9000"
+`,
+	}}
+
+	src := &ComposeSource{Path: "/repo/docker-compose.yml", FS: fs}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	byName := make(map[string]Candidate)
+	for _, c := range got {
+		byName[c.PackageName] = c
+	}
+	assert.Equal(t, 3000, byName["web"].Port)
+	assert.Equal(t, "compose", byName["web"].Reason)
+	assert.Equal(t, 8080, byName["api"].Port)
+	assert.Equal(t, 9000, byName["worker"].Port)
+	assert.Equal(t, "compose-expose", byName["worker"].Reason)
+}
+
+func TestComposeSource_DiscoverPropagatesReadError(t *testing.T) {
+	src := &ComposeSource{Path: "/repo/docker-compose.yml", FS: &fakeFileReader{}}
+	_, err := src.Discover(context.Background())
+	assert.Error(t, err)
+}
+
+func TestHostPortFromComposePortMapping(t *testing.T) {
+	tests := []struct {
+		mapping  string
+		wantPort int
+		wantOK   bool
+	}{
+		{"3000", 3000, true},
+		{"8080:80", 8080, true},
+		{"127.0.0.1:8080:80/tcp", 8080, true},
+		{"3000:3000/udp", 3000, true},
+		{"not-a-port", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range tests {
+		port, ok := hostPortFromComposePortMapping(tc.mapping)
+		assert.Equal(t, tc.wantOK, ok, tc.mapping)
+		if tc.wantOK {
+			assert.Equal(t, tc.wantPort, port, tc.mapping)
+		}
+	}
+}
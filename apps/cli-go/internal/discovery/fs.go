@@ -0,0 +1,14 @@
+package discovery
+
+import "os"
+
+// FileReader abstracts a single file read, so sources that only need to
+// read a handful of named files (not glob or walk a tree) can be tested
+// without touching disk.
+type FileReader interface {
+	ReadFile(path string) ([]byte, error)
+}
+
+type osFileReader struct{}
+
+func (osFileReader) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
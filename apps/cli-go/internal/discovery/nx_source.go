@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nkootstra/xpose/internal/turbo"
+)
+
+// nxServeTargetNames are checked in order; the first target present on a
+// project wins, mirroring how `nx serve`/`nx dev`/`nx start` are the
+// conventional entry points for running a project locally.
+var nxServeTargetNames = []string{"serve", "dev", "start"}
+
+// NxSource discovers dev-server ports from an Nx monorepo: it lists
+// projects via `nx show projects --json`, then asks `nx show project
+// <name> --json` for each one's root and targets, taking the port or
+// command off the first recognized serve target.
+type NxSource struct {
+	Cwd    string
+	Runner turbo.CommandRunner
+}
+
+// NewNxSource creates an NxSource rooted at cwd, using the OS to run nx.
+func NewNxSource(cwd string) *NxSource {
+	return &NxSource{Cwd: cwd, Runner: &turbo.ExecCommandRunner{}}
+}
+
+type nxProjectDetail struct {
+	Name    string `json:"name"`
+	Root    string `json:"root"`
+	Targets map[string]struct {
+		Options struct {
+			Port    int    `json:"port"`
+			Command string `json:"command"`
+		} `json:"options"`
+	} `json:"targets"`
+}
+
+func (s *NxSource) Discover(ctx context.Context) ([]Candidate, error) {
+	runner := s.Runner
+	if runner == nil {
+		runner = &turbo.ExecCommandRunner{}
+	}
+
+	output, err := runner.Run(ctx, "nx", []string{"show", "projects", "--json"}, s.Cwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run nx show projects: %w", err)
+	}
+
+	var projects []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse nx show projects output: %w", err)
+	}
+
+	var candidates []Candidate
+	for _, project := range projects {
+		detailOutput, err := runner.Run(ctx, "nx", []string{"show", "project", project, "--json"}, s.Cwd)
+		if err != nil {
+			continue
+		}
+
+		var detail nxProjectDetail
+		if err := json.Unmarshal([]byte(detailOutput), &detail); err != nil {
+			continue
+		}
+
+		if c, ok := candidateFromNxProject(project, detail); ok {
+			candidates = append(candidates, c)
+		}
+	}
+
+	return candidates, nil
+}
+
+func candidateFromNxProject(project string, detail nxProjectDetail) (Candidate, bool) {
+	for _, targetName := range nxServeTargetNames {
+		target, ok := detail.Targets[targetName]
+		if !ok {
+			continue
+		}
+
+		if target.Options.Port > 0 {
+			return Candidate{
+				Port:        target.Options.Port,
+				PackageName: project,
+				Directory:   detail.Root,
+				Reason:      "explicit",
+			}, true
+		}
+
+		command := target.Options.Command
+		if command == "" {
+			continue
+		}
+		if ports := turbo.ExtractExplicitPorts(command); len(ports) > 0 {
+			return Candidate{
+				Port:        ports[0],
+				PackageName: project,
+				Directory:   detail.Root,
+				Command:     command,
+				Reason:      "explicit",
+			}, true
+		}
+		if port := turbo.InferDefaultPort(command); port != nil {
+			return Candidate{
+				Port:        *port,
+				PackageName: project,
+				Directory:   detail.Root,
+				Command:     command,
+				Reason:      "default",
+			}, true
+		}
+	}
+	return Candidate{}, false
+}
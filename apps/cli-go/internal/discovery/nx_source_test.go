@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// routingRunner returns canned output keyed by args[1] ("projects" or
+// "project"), so a single mock can answer both the project-list call and
+// the per-project detail calls NxSource.Discover makes.
+type routingRunner struct {
+	projectsOutput string
+	detailOutput   map[string]string
+}
+
+func (r *routingRunner) Run(_ context.Context, _ string, args []string, _ string) (string, error) {
+	if len(args) >= 2 && args[1] == "projects" {
+		return r.projectsOutput, nil
+	}
+	if len(args) >= 3 && args[1] == "project" {
+		return r.detailOutput[args[2]], nil
+	}
+	return "", nil
+}
+
+func TestNxSource_Discover(t *testing.T) {
+	runner := &routingRunner{
+		projectsOutput: `["web", "api"]`,
+		detailOutput: map[string]string{
+			"web": `{"name": "web", "root": "apps/web", "targets": {"serve": {"options": {"port": 4200}}}}`,
+			"api": `{"name": "api", "root": "apps/api", "targets": {"serve": {"options": {"command": "wrangler dev"}}}}`,
+		},
+	}
+
+	src := &NxSource{Runner: runner}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	assert.Equal(t, 4200, got[0].Port)
+	assert.Equal(t, "apps/web", got[0].Directory)
+	assert.Equal(t, "explicit", got[0].Reason)
+
+	assert.Equal(t, 8787, got[1].Port)
+	assert.Equal(t, "default", got[1].Reason)
+}
+
+func TestNxSource_Discover_SkipsProjectsWithNoServeTarget(t *testing.T) {
+	runner := &routingRunner{
+		projectsOutput: `["shared-lib"]`,
+		detailOutput: map[string]string{
+			"shared-lib": `{"name": "shared-lib", "root": "libs/shared", "targets": {"build": {}}}`,
+		},
+	}
+
+	src := &NxSource{Runner: runner}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestNxSource_DiscoverPropagatesProjectListError(t *testing.T) {
+	src := &NxSource{Runner: &mockRunner{err: assert.AnError}}
+
+	_, err := src.Discover(context.Background())
+	assert.Error(t, err)
+}
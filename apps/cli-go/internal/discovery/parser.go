@@ -0,0 +1,24 @@
+package discovery
+
+import "github.com/nkootstra/xpose/internal/turbo"
+
+// CommandParser extracts port information from a dev-server command line
+// (e.g. "next dev --port 3000" or "vite"). It reuses the same regex
+// battery turbo.DiscoverTurboPorts uses internally, so every source that
+// has a command line (an Nx target, a workspace "scripts.dev") gets the
+// same framework/flag coverage for free instead of reimplementing it.
+type CommandParser struct{}
+
+// ExplicitPorts returns every port explicitly named in command (via
+// PORT=, --port, -p, --listen, or a literal host:port URL), ascending and
+// deduplicated.
+func (CommandParser) ExplicitPorts(command string) []int {
+	return turbo.ExtractExplicitPorts(command)
+}
+
+// DefaultPort returns the conventional port for a recognized dev-server
+// framework invocation (e.g. "next dev" -> 3000), or nil if command
+// doesn't match a known framework.
+func (CommandParser) DefaultPort(command string) *int {
+	return turbo.InferDefaultPort(command)
+}
@@ -0,0 +1,23 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandParser_ExplicitPorts(t *testing.T) {
+	got := CommandParser{}.ExplicitPorts("PORT=4000 next dev")
+	assert.Equal(t, []int{4000}, got)
+}
+
+func TestCommandParser_DefaultPort(t *testing.T) {
+	got := CommandParser{}.DefaultPort("wrangler dev")
+	assert.NotNil(t, got)
+	assert.Equal(t, 8787, *got)
+}
+
+func TestCommandParser_DefaultPortUnknownCommand(t *testing.T) {
+	got := CommandParser{}.DefaultPort("node server.js")
+	assert.Nil(t, got)
+}
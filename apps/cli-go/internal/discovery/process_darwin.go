@@ -0,0 +1,37 @@
+//go:build darwin
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// lsofLister finds listeners by shelling out to lsof, since macOS has no
+// /proc filesystem to read directly.
+type lsofLister struct{}
+
+func newPlatformProcessLister() processLister { return lsofLister{} }
+
+func (lsofLister) listListeningPorts(ctx context.Context) ([]processListener, error) {
+	out, err := exec.CommandContext(ctx, "lsof", "-iTCP", "-sTCP:LISTEN", "-nP").CombinedOutput()
+	if err != nil {
+		if len(out) == 0 {
+			return nil, fmt.Errorf("lsof failed: %w", err)
+		}
+		// lsof exits non-zero if any of its other default checks fail even
+		// when it still produced a usable listener table; fall through.
+	}
+	return parseLsofOutput(string(out)), nil
+}
+
+func (lsofLister) processInfo(pid int) (cmdline, cwd string, err error) {
+	out, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).CombinedOutput()
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(string(out)), "", nil
+}
@@ -0,0 +1,98 @@
+//go:build linux
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// procfsLister finds listeners by reading /proc/net/tcp{,6} and
+// correlating each socket's inode to a PID via /proc/[pid]/fd symlinks.
+type procfsLister struct{}
+
+func newPlatformProcessLister() processLister { return procfsLister{} }
+
+func (procfsLister) listListeningPorts(ctx context.Context) ([]processListener, error) {
+	var sockets []procSocket
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			continue // e.g. IPv6 disabled; not fatal, the other table still counts
+		}
+		sockets = append(sockets, parseProcNetTCP(string(contents))...)
+	}
+	if len(sockets) == 0 {
+		return nil, nil
+	}
+
+	inodeToPID := inodeOwners()
+
+	var listeners []processListener
+	for _, s := range sockets {
+		pid, ok := inodeToPID[s.Inode]
+		if !ok {
+			continue
+		}
+		listeners = append(listeners, processListener{Port: s.Port, PID: pid})
+	}
+	return listeners, nil
+}
+
+// inodeOwners walks /proc/[pid]/fd, mapping each open socket's inode (from
+// a "socket:[12345]" symlink target) to the PID holding it open.
+func inodeOwners() map[uint64]int {
+	owners := make(map[uint64]int)
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		fds, err := os.ReadDir(fmt.Sprintf("/proc/%d/fd", pid))
+		if err != nil {
+			continue // exited, or not ours to inspect
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%s", pid, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inodeStr := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+			inode, err := strconv.ParseUint(inodeStr, 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, exists := owners[inode]; !exists {
+				owners[inode] = pid
+			}
+		}
+	}
+	return owners
+}
+
+func (procfsLister) processInfo(pid int) (cmdline, cwd string, err error) {
+	cmdlineBytes, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return "", "", err
+	}
+	cmdline = strings.TrimRight(strings.ReplaceAll(string(cmdlineBytes), "\x00", " "), " ")
+
+	cwd, err = os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	if err != nil {
+		return cmdline, "", nil
+	}
+	return cmdline, cwd, nil
+}
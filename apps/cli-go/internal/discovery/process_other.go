@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+
+package discovery
+
+import "context"
+
+// unsupportedProcessLister backs ProcessSource on platforms without a
+// known listener-enumeration mechanism. It contributes nothing rather than
+// erroring, so Discover's other sources are unaffected.
+type unsupportedProcessLister struct{}
+
+func newPlatformProcessLister() processLister { return unsupportedProcessLister{} }
+
+func (unsupportedProcessLister) listListeningPorts(ctx context.Context) ([]processListener, error) {
+	return nil, nil
+}
+
+func (unsupportedProcessLister) processInfo(pid int) (cmdline, cwd string, err error) {
+	return "", "", nil
+}
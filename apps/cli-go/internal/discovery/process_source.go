@@ -0,0 +1,155 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ProcessSource discovers local dev servers by scanning the live process
+// table for loopback listeners, rather than inspecting any build
+// configuration. It's the fallback source: useful for a server started by
+// hand outside any monorepo task runner or compose file.
+type ProcessSource struct {
+	lister processLister
+}
+
+// NewProcessSource creates a ProcessSource using the current platform's
+// listener lister (procfs on Linux, lsof on macOS, a no-op elsewhere).
+func NewProcessSource() *ProcessSource {
+	return &ProcessSource{lister: newPlatformProcessLister()}
+}
+
+// processListener is one TCP socket in LISTEN state, as reported by the
+// platform-specific lister.
+type processListener struct {
+	Port int
+	PID  int
+}
+
+// processLister enumerates listening TCP sockets and looks up the command
+// line/working directory behind one, so the platform backends (procfs,
+// lsof) can share Discover's candidate-building logic below.
+type processLister interface {
+	listListeningPorts(ctx context.Context) ([]processListener, error)
+	processInfo(pid int) (cmdline, cwd string, err error)
+}
+
+func (s *ProcessSource) Discover(ctx context.Context) ([]Candidate, error) {
+	lister := s.lister
+	if lister == nil {
+		lister = newPlatformProcessLister()
+	}
+
+	listeners, err := lister.listListeningPorts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list listening ports: %w", err)
+	}
+
+	seenPorts := make(map[int]struct{})
+	var candidates []Candidate
+	for _, l := range listeners {
+		if _, exists := seenPorts[l.Port]; exists {
+			continue
+		}
+		seenPorts[l.Port] = struct{}{}
+
+		cmdline, cwd, err := lister.processInfo(l.PID)
+		if err != nil {
+			// The process could have exited between listing and lookup, or we
+			// lack permission to inspect it; still report the bare port.
+			candidates = append(candidates, Candidate{Port: l.Port, Reason: "listening"})
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Port:        l.Port,
+			PackageName: filepath.Base(cwd),
+			Directory:   cwd,
+			Command:     cmdline,
+			Reason:      "listening",
+		})
+	}
+	return candidates, nil
+}
+
+// procSocket is one row parsed out of /proc/net/tcp{,6}.
+type procSocket struct {
+	Port  int
+	Inode uint64
+}
+
+// parseProcNetTCP parses the contents of /proc/net/tcp or /proc/net/tcp6,
+// returning the (port, inode) of every socket in LISTEN state (st == 0A).
+// The format is a fixed-width whitespace-separated table; see proc(5).
+func parseProcNetTCP(contents string) []procSocket {
+	var sockets []procSocket
+	lines := strings.Split(contents, "\n")
+	if len(lines) > 0 {
+		lines = lines[1:] // header row
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		if fields[3] != "0A" { // TCP_LISTEN
+			continue
+		}
+
+		localAddr := fields[1]
+		colon := strings.LastIndexByte(localAddr, ':')
+		if colon < 0 {
+			continue
+		}
+		port, err := strconv.ParseInt(localAddr[colon+1:], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		sockets = append(sockets, procSocket{Port: int(port), Inode: inode})
+	}
+	return sockets
+}
+
+// parseLsofOutput parses `lsof -iTCP -sTCP:LISTEN -nP` output, a
+// whitespace-separated table with a header row and a NAME column like
+// "*:3000" or "127.0.0.1:3000" (no "(LISTEN)" suffix with -sTCP:LISTEN
+// already filtering to listeners, but tolerate it if present).
+func parseLsofOutput(output string) []processListener {
+	var listeners []processListener
+	lines := strings.Split(output, "\n")
+	if len(lines) > 0 {
+		lines = lines[1:] // header row
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		name := fields[8]
+		colon := strings.LastIndexByte(name, ':')
+		if colon < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(name[colon+1:])
+		if err != nil {
+			continue
+		}
+
+		listeners = append(listeners, processListener{Port: port, PID: pid})
+	}
+	return listeners
+}
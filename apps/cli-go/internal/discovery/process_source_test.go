@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcNetTCP(t *testing.T) {
+	contents := "  sl  local_address rem_address   st\n" +
+		"   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0\n" +
+		"   1: 00000000:9C4C 00000000:0000 01 00000000:00000000 00:00000000 00000000     0        0 23456 1 0000000000000000 100 0 0 10 0\n"
+
+	got := parseProcNetTCP(contents)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 0x1F90, got[0].Port)
+	assert.Equal(t, uint64(12345), got[0].Inode)
+}
+
+func TestParseProcNetTCP_MalformedRowsIgnored(t *testing.T) {
+	contents := "header\nnot enough fields\n"
+	got := parseProcNetTCP(contents)
+	assert.Empty(t, got)
+}
+
+func TestParseLsofOutput(t *testing.T) {
+	output := "COMMAND   PID   USER   FD   TYPE DEVICE SIZE/OFF NODE NAME\n" +
+		"node      4242  user   23u  IPv4 0x123  0t0      TCP  *:3000 (LISTEN)\n" +
+		"node      4242  user   24u  IPv4 0x124  0t0      TCP  127.0.0.1:5173 (LISTEN)\n"
+
+	got := parseLsofOutput(output)
+	assert.Len(t, got, 2)
+	assert.Equal(t, 3000, got[0].Port)
+	assert.Equal(t, 4242, got[0].PID)
+	assert.Equal(t, 5173, got[1].Port)
+}
+
+type fakeProcessLister struct {
+	listeners []processListener
+	listErr   error
+	info      map[int]struct {
+		cmdline string
+		cwd     string
+		err     error
+	}
+}
+
+func (f *fakeProcessLister) listListeningPorts(_ context.Context) ([]processListener, error) {
+	return f.listeners, f.listErr
+}
+
+func (f *fakeProcessLister) processInfo(pid int) (string, string, error) {
+	info, ok := f.info[pid]
+	if !ok {
+		return "", "", fmt.Errorf("no such process: %d", pid)
+	}
+	return info.cmdline, info.cwd, info.err
+}
+
+func TestProcessSource_Discover(t *testing.T) {
+	lister := &fakeProcessLister{
+		listeners: []processListener{{Port: 3000, PID: 4242}},
+		info: map[int]struct {
+			cmdline string
+			cwd     string
+			err     error
+		}{
+			4242: {cmdline: "next dev", cwd: "/repo/apps/web"},
+		},
+	}
+
+	src := &ProcessSource{lister: lister}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 3000, got[0].Port)
+	assert.Equal(t, "web", got[0].PackageName)
+	assert.Equal(t, "next dev", got[0].Command)
+}
+
+func TestProcessSource_Discover_FallsBackToBarePortWhenProcessInfoFails(t *testing.T) {
+	lister := &fakeProcessLister{
+		listeners: []processListener{{Port: 3000, PID: 4242}},
+		info: map[int]struct {
+			cmdline string
+			cwd     string
+			err     error
+		}{},
+	}
+
+	src := &ProcessSource{lister: lister}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 3000, got[0].Port)
+	assert.Empty(t, got[0].PackageName)
+}
+
+func TestProcessSource_Discover_DeduplicatesPorts(t *testing.T) {
+	lister := &fakeProcessLister{
+		listeners: []processListener{{Port: 3000, PID: 1}, {Port: 3000, PID: 2}},
+	}
+
+	src := &ProcessSource{lister: lister}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestProcessSource_DiscoverPropagatesListError(t *testing.T) {
+	src := &ProcessSource{lister: &fakeProcessLister{listErr: assert.AnError}}
+	_, err := src.Discover(context.Background())
+	assert.Error(t, err)
+}
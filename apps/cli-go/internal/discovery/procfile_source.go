@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nkootstra/xpose/internal/turbo"
+)
+
+// ProcfileSource discovers dev-server ports from a Foreman-style Procfile:
+// each "name: command" line is parsed the same way turbo parses a task
+// command, so a plain Procfile-based project (no monorepo task runner or
+// compose file) is still discoverable.
+type ProcfileSource struct {
+	Path string
+	FS   FileReader
+}
+
+// NewProcfileSource creates a ProcfileSource reading the Procfile at path
+// (e.g. "Procfile"), using the OS filesystem.
+func NewProcfileSource(path string) *ProcfileSource {
+	return &ProcfileSource{Path: path, FS: osFileReader{}}
+}
+
+func (s *ProcfileSource) Discover(ctx context.Context) ([]Candidate, error) {
+	fs := s.FS
+	if fs == nil {
+		fs = osFileReader{}
+	}
+
+	data, err := fs.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.Path, err)
+	}
+
+	dir := filepath.Dir(s.Path)
+
+	var candidates []Candidate
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		name, command, ok := parseProcfileLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		if ports := turbo.ExtractExplicitPorts(command); len(ports) > 0 {
+			candidates = append(candidates, Candidate{
+				Port:        ports[0],
+				PackageName: name,
+				Directory:   dir,
+				Command:     command,
+				Reason:      "explicit",
+			})
+			continue
+		}
+		if port := turbo.InferDefaultPort(command); port != nil {
+			candidates = append(candidates, Candidate{
+				Port:        *port,
+				PackageName: name,
+				Directory:   dir,
+				Command:     command,
+				Reason:      "default",
+			})
+		}
+	}
+
+	return candidates, nil
+}
+
+// parseProcfileLine splits a Procfile line into its process name and
+// command, e.g. "web: next dev -p 3000" -> ("web", "next dev -p 3000").
+// Blank lines and "#"-prefixed comments are not entries.
+func parseProcfileLine(line string) (name, command string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	idx := strings.IndexByte(line, ':')
+	if idx < 0 {
+		return "", "", false
+	}
+
+	name = strings.TrimSpace(line[:idx])
+	command = strings.TrimSpace(line[idx+1:])
+	if name == "" || command == "" {
+		return "", "", false
+	}
+	return name, command, true
+}
@@ -0,0 +1,59 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcfileSource_Discover(t *testing.T) {
+	fs := &fakeFileReader{files: map[string]string{
+		"/repo/Procfile": "# comment\nweb: next dev -p 3000\nworker: node worker.js\napi: nuxt dev\n",
+	}}
+
+	src := &ProcfileSource{Path: "/repo/Procfile", FS: fs}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	byName := make(map[string]Candidate)
+	for _, c := range got {
+		byName[c.PackageName] = c
+	}
+	assert.Equal(t, 3000, byName["web"].Port)
+	assert.Equal(t, "explicit", byName["web"].Reason)
+	assert.Equal(t, 3000, byName["api"].Port)
+	assert.Equal(t, "default", byName["api"].Reason)
+	assert.NotContains(t, byName, "worker")
+}
+
+func TestProcfileSource_DiscoverPropagatesReadError(t *testing.T) {
+	src := &ProcfileSource{Path: "/repo/Procfile", FS: &fakeFileReader{}}
+	_, err := src.Discover(context.Background())
+	assert.Error(t, err)
+}
+
+func TestParseProcfileLine(t *testing.T) {
+	tests := []struct {
+		line        string
+		wantName    string
+		wantCommand string
+		wantOK      bool
+	}{
+		{"web: next dev", "web", "next dev", true},
+		{"  web:   next dev  ", "web", "next dev", true},
+		{"# web: next dev", "", "", false},
+		{"", "", "", false},
+		{"no colon here", "", "", false},
+		{"web:", "", "", false},
+	}
+	for _, tc := range tests {
+		name, command, ok := parseProcfileLine(tc.line)
+		assert.Equal(t, tc.wantOK, ok, tc.line)
+		if tc.wantOK {
+			assert.Equal(t, tc.wantName, name, tc.line)
+			assert.Equal(t, tc.wantCommand, command, tc.line)
+		}
+	}
+}
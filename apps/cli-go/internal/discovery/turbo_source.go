@@ -0,0 +1,39 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/nkootstra/xpose/internal/turbo"
+)
+
+// TurboSource adapts turbo.DiscoverTurboPorts to the Source interface, so
+// it can be combined with the other monorepo/process sources behind one
+// Discover call.
+type TurboSource struct {
+	Opts   turbo.DiscoverOptions
+	Runner turbo.CommandRunner
+}
+
+// NewTurboSource creates a TurboSource rooted at cwd for the given task.
+func NewTurboSource(cwd, task, filter string) *TurboSource {
+	return &TurboSource{Opts: turbo.DiscoverOptions{Cwd: cwd, Task: task, Filter: filter}}
+}
+
+func (s *TurboSource) Discover(ctx context.Context) ([]Candidate, error) {
+	ports, err := turbo.DiscoverTurboPorts(ctx, s.Opts, s.Runner)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, len(ports))
+	for i, p := range ports {
+		candidates[i] = Candidate{
+			Port:        p.Port,
+			PackageName: p.PackageName,
+			Directory:   p.Directory,
+			Command:     p.Command,
+			Reason:      p.Reason,
+		}
+	}
+	return candidates, nil
+}
@@ -0,0 +1,41 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRunner struct {
+	output string
+	err    error
+}
+
+func (m *mockRunner) Run(_ context.Context, _ string, _ []string, _ string) (string, error) {
+	return m.output, m.err
+}
+
+func TestTurboSource_Discover(t *testing.T) {
+	runner := &mockRunner{output: `{
+		"tasks": [
+			{"command": "next dev --port 3000", "package": "@myapp/web", "directory": "apps/web"}
+		]
+	}`}
+
+	src := &TurboSource{Runner: runner}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 3000, got[0].Port)
+	assert.Equal(t, "@myapp/web", got[0].PackageName)
+	assert.Equal(t, "explicit", got[0].Reason)
+}
+
+func TestTurboSource_DiscoverPropagatesError(t *testing.T) {
+	runner := &mockRunner{err: assert.AnError}
+	src := &TurboSource{Runner: runner}
+
+	_, err := src.Discover(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nkootstra/xpose/internal/turbo"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceSource discovers dev-server ports from a pnpm or Yarn/npm
+// workspaces monorepo: it resolves the workspace package globs (from
+// pnpm-workspace.yaml, or the root package.json's "workspaces" field),
+// expands them, and parses each member's package.json "scripts.dev" the
+// same way turbo parses a task command.
+type WorkspaceSource struct {
+	Cwd string
+	FS  WorkspaceFS
+}
+
+// WorkspaceFS abstracts the filesystem calls WorkspaceSource needs, so
+// tests can supply an in-memory fake instead of touching disk.
+type WorkspaceFS interface {
+	ReadFile(path string) ([]byte, error)
+	Glob(pattern string) ([]string, error)
+}
+
+// NewWorkspaceSource creates a WorkspaceSource rooted at cwd, using the OS
+// filesystem.
+func NewWorkspaceSource(cwd string) *WorkspaceSource {
+	return &WorkspaceSource{Cwd: cwd, FS: osWorkspaceFS{}}
+}
+
+type packageJSON struct {
+	Name       string            `json:"name"`
+	Scripts    map[string]string `json:"scripts"`
+	Workspaces json.RawMessage   `json:"workspaces"`
+}
+
+type pnpmWorkspaceYAML struct {
+	Packages []string `yaml:"packages"`
+}
+
+func (s *WorkspaceSource) Discover(ctx context.Context) ([]Candidate, error) {
+	fs := s.FS
+	if fs == nil {
+		fs = osWorkspaceFS{}
+	}
+
+	globs, err := s.workspaceGlobs(fs)
+	if err != nil {
+		return nil, err
+	}
+	if len(globs) == 0 {
+		return nil, fmt.Errorf("no workspace globs found under %s", s.Cwd)
+	}
+
+	var dirs []string
+	seenDirs := make(map[string]struct{})
+	for _, glob := range globs {
+		matches, err := fs.Glob(filepath.Join(s.Cwd, glob))
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			if _, exists := seenDirs[m]; exists {
+				continue
+			}
+			seenDirs[m] = struct{}{}
+			dirs = append(dirs, m)
+		}
+	}
+
+	var candidates []Candidate
+	for _, dir := range dirs {
+		data, err := fs.ReadFile(filepath.Join(dir, "package.json"))
+		if err != nil {
+			continue
+		}
+		var pkg packageJSON
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			continue
+		}
+		devScript := strings.TrimSpace(pkg.Scripts["dev"])
+		if devScript == "" {
+			continue
+		}
+
+		name := pkg.Name
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+
+		if ports := turbo.ExtractExplicitPorts(devScript); len(ports) > 0 {
+			candidates = append(candidates, Candidate{Port: ports[0], PackageName: name, Directory: dir, Command: devScript, Reason: "explicit"})
+			continue
+		}
+		if port := turbo.InferDefaultPort(devScript); port != nil {
+			candidates = append(candidates, Candidate{Port: *port, PackageName: name, Directory: dir, Command: devScript, Reason: "default"})
+		}
+	}
+
+	return candidates, nil
+}
+
+// workspaceGlobs resolves the member package globs: pnpm-workspace.yaml
+// takes priority if present (it's pnpm-specific and unambiguous),
+// otherwise the root package.json's "workspaces" field (a plain array, or
+// an object with a "packages" array, as Yarn also supports).
+func (s *WorkspaceSource) workspaceGlobs(fs WorkspaceFS) ([]string, error) {
+	if data, err := fs.ReadFile(filepath.Join(s.Cwd, "pnpm-workspace.yaml")); err == nil {
+		var parsed pnpmWorkspaceYAML
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse pnpm-workspace.yaml: %w", err)
+		}
+		return parsed.Packages, nil
+	}
+
+	data, err := fs.ReadFile(filepath.Join(s.Cwd, "package.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %w", err)
+	}
+
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %w", err)
+	}
+	if len(pkg.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var globs []string
+	if err := json.Unmarshal(pkg.Workspaces, &globs); err == nil {
+		return globs, nil
+	}
+
+	var withPackages struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(pkg.Workspaces, &withPackages); err == nil {
+		return withPackages.Packages, nil
+	}
+
+	return nil, fmt.Errorf("unrecognized \"workspaces\" field shape in package.json")
+}
+
+type osWorkspaceFS struct{}
+
+func (osWorkspaceFS) ReadFile(path string) ([]byte, error)  { return os.ReadFile(path) }
+func (osWorkspaceFS) Glob(pattern string) ([]string, error) { return filepath.Glob(pattern) }
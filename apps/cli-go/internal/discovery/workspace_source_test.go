@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWorkspaceFS is an in-memory WorkspaceFS: files is keyed by exact
+// path, and globs maps a glob pattern to the paths it should expand to
+// (filepath.Glob itself isn't exercised, since the point is to test
+// WorkspaceSource's logic, not the stdlib).
+type fakeWorkspaceFS struct {
+	files map[string]string
+	globs map[string][]string
+}
+
+func (f *fakeWorkspaceFS) ReadFile(path string) ([]byte, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("no such file: %s", path)
+	}
+	return []byte(content), nil
+}
+
+func (f *fakeWorkspaceFS) Glob(pattern string) ([]string, error) {
+	return f.globs[pattern], nil
+}
+
+func TestWorkspaceSource_Discover_NpmYarnWorkspacesArray(t *testing.T) {
+	fs := &fakeWorkspaceFS{
+		files: map[string]string{
+			"/repo/package.json":           `{"workspaces": ["apps/*"]}`,
+			"/repo/apps/web/package.json":  `{"name": "web", "scripts": {"dev": "next dev --port 3000"}}`,
+			"/repo/apps/docs/package.json": `{"name": "docs", "scripts": {"dev": "vite"}}`,
+		},
+		globs: map[string][]string{
+			"/repo/apps/*": {"/repo/apps/web", "/repo/apps/docs"},
+		},
+	}
+
+	src := &WorkspaceSource{Cwd: "/repo", FS: fs}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, 3000, got[0].Port)
+	assert.Equal(t, "explicit", got[0].Reason)
+	assert.Equal(t, 5173, got[1].Port)
+	assert.Equal(t, "default", got[1].Reason)
+}
+
+func TestWorkspaceSource_Discover_YarnWorkspacesObjectForm(t *testing.T) {
+	fs := &fakeWorkspaceFS{
+		files: map[string]string{
+			"/repo/package.json":          `{"workspaces": {"packages": ["apps/*"]}}`,
+			"/repo/apps/api/package.json": `{"name": "api", "scripts": {"dev": "wrangler dev"}}`,
+		},
+		globs: map[string][]string{
+			"/repo/apps/*": {"/repo/apps/api"},
+		},
+	}
+
+	src := &WorkspaceSource{Cwd: "/repo", FS: fs}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 8787, got[0].Port)
+}
+
+func TestWorkspaceSource_Discover_PnpmWorkspaceYAMLTakesPriority(t *testing.T) {
+	fs := &fakeWorkspaceFS{
+		files: map[string]string{
+			"/repo/pnpm-workspace.yaml":   "packages:\n  - apps/*\n",
+			"/repo/package.json":          `{"workspaces": ["should-be-ignored"]}`,
+			"/repo/apps/web/package.json": `{"name": "web", "scripts": {"dev": "next dev --port 3000"}}`,
+		},
+		globs: map[string][]string{
+			"/repo/apps/*": {"/repo/apps/web"},
+		},
+	}
+
+	src := &WorkspaceSource{Cwd: "/repo", FS: fs}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, 3000, got[0].Port)
+}
+
+func TestWorkspaceSource_Discover_SkipsMembersWithoutDevScript(t *testing.T) {
+	fs := &fakeWorkspaceFS{
+		files: map[string]string{
+			"/repo/package.json":              `{"workspaces": ["packages/*"]}`,
+			"/repo/packages/lib/package.json": `{"name": "lib", "scripts": {"build": "tsc"}}`,
+		},
+		globs: map[string][]string{
+			"/repo/packages/*": {"/repo/packages/lib"},
+		},
+	}
+
+	src := &WorkspaceSource{Cwd: "/repo", FS: fs}
+	got, err := src.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestWorkspaceSource_Discover_NoWorkspacesFieldErrors(t *testing.T) {
+	fs := &fakeWorkspaceFS{
+		files: map[string]string{
+			"/repo/package.json": `{"name": "repo-root"}`,
+		},
+	}
+
+	src := &WorkspaceSource{Cwd: "/repo", FS: fs}
+	_, err := src.Discover(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package har
+
+import "io"
+
+// maxCaptureBodyBytes bounds how much of a streamed response body is kept
+// for HAR capture, independent of the tunnel's own max-body-size limit, so
+// a large or unbounded stream (e.g. SSE) can't balloon capture memory.
+const maxCaptureBodyBytes = 256 * 1024
+
+// CaptureReader wraps an io.Reader, copying up to maxCaptureBodyBytes of
+// what passes through into an internal buffer while forwarding every byte
+// to the caller unchanged. It's used to capture a response body for HAR
+// recording without disturbing the existing streaming proxy path.
+type CaptureReader struct {
+	src       io.Reader
+	captured  []byte
+	truncated bool
+}
+
+// NewCaptureReader wraps src for HAR capture.
+func NewCaptureReader(src io.Reader) *CaptureReader {
+	return &CaptureReader{src: src}
+}
+
+func (c *CaptureReader) Read(p []byte) (int, error) {
+	n, err := c.src.Read(p)
+	if n > 0 {
+		remaining := maxCaptureBodyBytes - len(c.captured)
+		if remaining <= 0 {
+			c.truncated = true
+		} else if n <= remaining {
+			c.captured = append(c.captured, p[:n]...)
+		} else {
+			c.captured = append(c.captured, p[:remaining]...)
+			c.truncated = true
+		}
+	}
+	return n, err
+}
+
+// Bytes returns the bytes captured so far (possibly truncated).
+func (c *CaptureReader) Bytes() []byte {
+	return c.captured
+}
+
+// Truncated reports whether the captured body was cut off at the cap.
+func (c *CaptureReader) Truncated() bool {
+	return c.truncated
+}
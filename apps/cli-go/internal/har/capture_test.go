@@ -0,0 +1,34 @@
+package har
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureReader_ForwardsAllBytes(t *testing.T) {
+	src := bytes.NewReader([]byte("hello world"))
+	cr := NewCaptureReader(src)
+
+	out, err := io.ReadAll(cr)
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello world", string(out))
+	assert.Equal(t, "hello world", string(cr.Bytes()))
+	assert.False(t, cr.Truncated())
+}
+
+func TestCaptureReader_CapsCapturedBytesButStillForwardsAll(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), maxCaptureBodyBytes+1024)
+	cr := NewCaptureReader(bytes.NewReader(body))
+
+	out, err := io.ReadAll(cr)
+	require.NoError(t, err)
+
+	assert.Len(t, out, len(body))
+	assert.LessOrEqual(t, len(cr.Bytes()), maxCaptureBodyBytes)
+	assert.True(t, cr.Truncated())
+}
@@ -0,0 +1,175 @@
+package har
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// EntryParams carries everything needed to build a single HAR entry from a
+// proxied request/response pair.
+type EntryParams struct {
+	StartedAt       time.Time
+	Duration        time.Duration
+	Method          string
+	Host            string
+	Path            string
+	RequestHeaders  map[string]string
+	RequestBody     []byte
+	Status          int
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	BodyTruncated   bool
+}
+
+// NewEntry builds a HAR entry from a captured request/response pair.
+// Bodies are stored as text when they decode as valid UTF-8, and as
+// base64 otherwise (matching the HAR 1.2 "encoding" convention for binary
+// content). A truncated response body (capped while streaming) is recorded
+// with its partial bytes rather than dropped.
+func NewEntry(p EntryParams) Entry {
+	rawURL := fmt.Sprintf("http://%s%s", p.Host, p.Path)
+	parsed, _ := url.Parse(rawURL)
+
+	var query []QueryParam
+	var headersSize int
+	if parsed != nil {
+		for key, values := range parsed.Query() {
+			for _, v := range values {
+				query = append(query, QueryParam{Name: key, Value: v})
+			}
+		}
+	}
+
+	reqHeaders := toHeaders(p.RequestHeaders)
+	for _, h := range reqHeaders {
+		headersSize += len(h.Name) + len(h.Value) + 4
+	}
+
+	req := Request{
+		Method:      p.Method,
+		URL:         rawURL,
+		HTTPVersion: "HTTP/1.1",
+		Headers:     reqHeaders,
+		QueryString: query,
+		HeadersSize: headersSize,
+		BodySize:    len(p.RequestBody),
+	}
+	if len(p.RequestBody) > 0 {
+		text, encoding := encodeBody(p.RequestBody)
+		req.PostData = &PostData{
+			MimeType: headerValue(p.RequestHeaders, "content-type"),
+			Text:     text,
+			Encoding: encoding,
+		}
+	}
+
+	respHeaders := toHeaders(p.ResponseHeaders)
+	var respHeadersSize int
+	for _, h := range respHeaders {
+		respHeadersSize += len(h.Name) + len(h.Value) + 4
+	}
+
+	content := Content{
+		Size:     len(p.ResponseBody),
+		MimeType: headerValue(p.ResponseHeaders, "content-type"),
+	}
+	if len(p.ResponseBody) > 0 {
+		content.Text, content.Encoding = encodeBody(p.ResponseBody)
+	}
+	if p.BodyTruncated {
+		content.Text += "\n[xpose: body truncated during capture]"
+	}
+
+	resp := Response{
+		Status:      p.Status,
+		StatusText:  statusText(p.Status),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     respHeaders,
+		Content:     content,
+		RedirectURL: headerValue(p.ResponseHeaders, "location"),
+		HeadersSize: respHeadersSize,
+		BodySize:    len(p.ResponseBody),
+	}
+
+	waitMs := float64(p.Duration.Microseconds()) / 1000.0
+
+	return Entry{
+		StartedDateTime: p.StartedAt.Format(time.RFC3339Nano),
+		Time:            waitMs,
+		Request:         req,
+		Response:        resp,
+		Cache:           Cache{},
+		Timings: Timings{
+			Send:    0,
+			Wait:    waitMs,
+			Receive: 0,
+		},
+	}
+}
+
+func toHeaders(m map[string]string) []Header {
+	headers := make([]Header, 0, len(m))
+	for name, value := range m {
+		headers = append(headers, Header{Name: name, Value: value})
+	}
+	return headers
+}
+
+func headerValue(m map[string]string, name string) string {
+	for key, value := range m {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// encodeBody returns body as text with no encoding when it is valid UTF-8,
+// or base64-encoded with encoding "base64" otherwise.
+func encodeBody(body []byte) (text string, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// statusText returns the conventional reason phrase for the common status
+// codes xpose proxies; unrecognized codes fall back to an empty string.
+func statusText(status int) string {
+	switch status {
+	case 200:
+		return "OK"
+	case 201:
+		return "Created"
+	case 204:
+		return "No Content"
+	case 301:
+		return "Moved Permanently"
+	case 302:
+		return "Found"
+	case 304:
+		return "Not Modified"
+	case 400:
+		return "Bad Request"
+	case 401:
+		return "Unauthorized"
+	case 403:
+		return "Forbidden"
+	case 404:
+		return "Not Found"
+	case 413:
+		return "Payload Too Large"
+	case 500:
+		return "Internal Server Error"
+	case 502:
+		return "Bad Gateway"
+	case 503:
+		return "Service Unavailable"
+	default:
+		return ""
+	}
+}
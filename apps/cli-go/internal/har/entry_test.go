@@ -0,0 +1,73 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEntry_TextBody(t *testing.T) {
+	entry := NewEntry(EntryParams{
+		StartedAt:       time.Now(),
+		Duration:        42 * time.Millisecond,
+		Method:          "POST",
+		Host:            "localhost:3000",
+		Path:            "/api/widgets?color=red",
+		RequestHeaders:  map[string]string{"Content-Type": "application/json"},
+		RequestBody:     []byte(`{"name":"widget"}`),
+		Status:          201,
+		ResponseHeaders: map[string]string{"Content-Type": "application/json"},
+		ResponseBody:    []byte(`{"id":1}`),
+	})
+
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "http://localhost:3000/api/widgets?color=red", entry.Request.URL)
+	assert.Equal(t, []QueryParam{{Name: "color", Value: "red"}}, entry.Request.QueryString)
+	assert.Equal(t, `{"name":"widget"}`, entry.Request.PostData.Text)
+	assert.Empty(t, entry.Request.PostData.Encoding)
+	assert.Equal(t, 201, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, `{"id":1}`, entry.Response.Content.Text)
+	assert.Equal(t, float64(42), entry.Timings.Wait)
+}
+
+func TestNewEntry_BinaryBodyIsBase64Encoded(t *testing.T) {
+	binary := []byte{0xff, 0xd8, 0xff, 0x00, 0x01, 0x02}
+
+	entry := NewEntry(EntryParams{
+		Method:       "GET",
+		Host:         "localhost:3000",
+		Path:         "/image.png",
+		Status:       200,
+		ResponseBody: binary,
+	})
+
+	assert.Equal(t, "base64", entry.Response.Content.Encoding)
+	assert.NotEmpty(t, entry.Response.Content.Text)
+}
+
+func TestNewEntry_RedirectURLFromLocationHeader(t *testing.T) {
+	entry := NewEntry(EntryParams{
+		Method:          "GET",
+		Host:            "localhost:3000",
+		Path:            "/old",
+		Status:          302,
+		ResponseHeaders: map[string]string{"Location": "/new"},
+	})
+
+	assert.Equal(t, "/new", entry.Response.RedirectURL)
+}
+
+func TestNewEntry_TruncatedBodyIsNoted(t *testing.T) {
+	entry := NewEntry(EntryParams{
+		Method:        "GET",
+		Host:          "localhost:3000",
+		Path:          "/stream",
+		Status:        200,
+		ResponseBody:  []byte("partial data"),
+		BodyTruncated: true,
+	})
+
+	assert.Contains(t, entry.Response.Content.Text, "truncated")
+}
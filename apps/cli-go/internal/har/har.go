@@ -0,0 +1,96 @@
+// Package har builds and persists HTTP Archive (HAR) 1.2 logs of proxied
+// traffic, and supports replaying a captured log against a local server.
+package har
+
+// Log is the root HAR 1.2 document.
+type Log struct {
+	Log LogBody `json:"log"`
+}
+
+// LogBody holds the HAR log's metadata and entries.
+type LogBody struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator identifies the tool that produced the log.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is a single captured request/response pair.
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Time            float64  `json:"time"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+	Cache           Cache    `json:"cache"`
+	Timings         Timings  `json:"timings"`
+}
+
+// Cache is always empty: xpose doesn't model cache state, but the HAR 1.2
+// spec requires every entry to carry the field.
+type Cache struct{}
+
+// Header is a single HTTP header as a name/value pair.
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// QueryParam is a single parsed query string parameter.
+type QueryParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData describes a captured request body.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Request describes the captured HTTP request.
+type Request struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []Header     `json:"headers"`
+	QueryString []QueryParam `json:"queryString"`
+	PostData    *PostData    `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+// Content describes a captured response body.
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Response describes the captured HTTP response.
+type Response struct {
+	Status      int      `json:"status"`
+	StatusText  string   `json:"statusText"`
+	HTTPVersion string   `json:"httpVersion"`
+	Headers     []Header `json:"headers"`
+	Content     Content  `json:"content"`
+	RedirectURL string   `json:"redirectURL"`
+	HeadersSize int      `json:"headersSize"`
+	BodySize    int      `json:"bodySize"`
+}
+
+// Timings holds the per-entry timing breakdown. Only wait is derived from
+// real data (the round-trip latency already surfaced in the traffic log);
+// send/receive are reported as zero since the client doesn't measure them
+// separately.
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
@@ -0,0 +1,55 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nkootstra/xpose/internal/version"
+)
+
+// Recorder accumulates HAR entries in memory and writes them out as a
+// single HAR 1.2 log. Like session state, the log is rewritten in full on
+// every Flush rather than streamed incrementally, so a capture in progress
+// still yields a valid file if xpose exits unexpectedly.
+type Recorder struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry
+}
+
+// NewRecorder creates a Recorder that writes to path on Flush.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Record appends a captured request/response pair.
+func (r *Recorder) Record(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// Flush writes all recorded entries to the HAR file, overwriting any
+// previous contents.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	log := Log{
+		Log: LogBody{
+			Version: "1.2",
+			Creator: Creator{Name: "xpose", Version: version.Version},
+			Entries: append([]Entry{}, r.entries...),
+		},
+	}
+	r.mu.Unlock()
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR log: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write HAR file %s: %w", r.path, err)
+	}
+	return nil
+}
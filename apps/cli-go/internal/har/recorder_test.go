@@ -0,0 +1,39 @@
+package har
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_FlushWritesValidHarLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.har")
+	r := NewRecorder(path)
+
+	r.Record(NewEntry(EntryParams{
+		Method: "GET",
+		Host:   "localhost:3000",
+		Path:   "/health",
+		Status: 200,
+	}))
+	r.Record(NewEntry(EntryParams{
+		Method: "POST",
+		Host:   "localhost:3000",
+		Path:   "/widgets",
+		Status: 201,
+	}))
+
+	require.NoError(t, r.Flush())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var log Log
+	require.NoError(t, json.Unmarshal(data, &log))
+	require.Equal(t, "1.2", log.Log.Version)
+	require.Len(t, log.Log.Entries, 2)
+	require.Equal(t, "http://localhost:3000/health", log.Log.Entries[0].Request.URL)
+}
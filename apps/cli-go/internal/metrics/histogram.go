@@ -0,0 +1,40 @@
+package metrics
+
+import "sort"
+
+// defaultDurationBuckets are the upper bounds (in seconds) used for the
+// request duration histogram, matching Prometheus's own client library
+// defaults.
+var defaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram is a fixed-bucket cumulative histogram, as used for Prometheus's
+// histogram metric type: each bucket counts observations less than or equal
+// to its upper bound, plus an implicit +Inf bucket covering everything.
+type histogram struct {
+	upperBounds []float64
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+func newHistogram(upperBounds []float64) *histogram {
+	bounds := make([]float64, len(upperBounds))
+	copy(bounds, upperBounds)
+	sort.Float64s(bounds)
+	return &histogram{
+		upperBounds: bounds,
+		counts:      make([]uint64, len(bounds)),
+	}
+}
+
+// observe records a single value, bumping every bucket whose upper bound is
+// greater than or equal to it.
+func (h *histogram) observe(v float64) {
+	h.sum += v
+	h.count++
+	for i, bound := range h.upperBounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Package metrics collects counters, gauges, and a request-duration
+// histogram for one or more tunnel.Client instances, and renders them in
+// Prometheus text exposition format for an optional local /metrics
+// endpoint (see cmd/root.go's --metrics flag).
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// tunnelUpState is one entry of Registry.tunnelUp, recording whether the
+// named tunnel's WebSocket connection is currently up and which local port
+// it forwards to, for the xpose_tunnel_up{subdomain,port} gauge.
+type tunnelUpState struct {
+	port int
+	up   bool
+}
+
+// requestLabelKey identifies one series of the per-tunnel
+// xpose_requests_total{subdomain,method,status} counter.
+type requestLabelKey struct {
+	subdomain string
+	method    string
+	status    int
+}
+
+// Registry is safe for concurrent use by multiple tunnel clients sharing a
+// single process.
+type Registry struct {
+	mu sync.Mutex
+
+	connectAttempts      int64
+	connectedTunnels     int64
+	authenticatedTunnels int64
+	reconnectBackoff     float64 // seconds
+	activeHTTPStreams    int64
+	activeWSStreams      int64
+	bytesIn              int64
+	bytesOut             int64
+	unknownMessages      int64
+	statusCodes          map[int]int64
+	requestDuration      *histogram
+
+	tunnelUp        map[string]tunnelUpState // subdomain -> state
+	requestsTotal   map[requestLabelKey]int64
+	activeStreams   map[string]int64     // subdomain -> in-flight request count
+	reconnectsTotal map[string]int64     // subdomain -> reconnect count
+	tunnelDeadline  map[string]time.Time // subdomain -> auth-ack expiry, for xpose_ttl_remaining_seconds
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		statusCodes:     make(map[int]int64),
+		tunnelUp:        make(map[string]tunnelUpState),
+		requestsTotal:   make(map[requestLabelKey]int64),
+		activeStreams:   make(map[string]int64),
+		reconnectsTotal: make(map[string]int64),
+		tunnelDeadline:  make(map[string]time.Time),
+		requestDuration: newHistogram(defaultDurationBuckets),
+	}
+}
+
+// IncConnectAttempts records a single WebSocket dial attempt.
+func (r *Registry) IncConnectAttempts() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectAttempts++
+}
+
+// IncConnectedTunnels marks one more tunnel as having an open WebSocket
+// connection.
+func (r *Registry) IncConnectedTunnels() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectedTunnels++
+}
+
+// DecConnectedTunnels marks one fewer tunnel as connected.
+func (r *Registry) DecConnectedTunnels() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectedTunnels--
+}
+
+// ConnectedTunnels returns the number of tunnels currently connected, for
+// the /healthz check.
+func (r *Registry) ConnectedTunnels() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connectedTunnels
+}
+
+// IncAuthenticatedTunnels marks one more tunnel as having received an
+// auth-ack on its current connection.
+func (r *Registry) IncAuthenticatedTunnels() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authenticatedTunnels++
+}
+
+// DecAuthenticatedTunnels marks one fewer tunnel as authenticated.
+func (r *Registry) DecAuthenticatedTunnels() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authenticatedTunnels--
+}
+
+// AuthenticatedTunnels returns the number of tunnels currently
+// authenticated, for the /readyz check.
+func (r *Registry) AuthenticatedTunnels() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.authenticatedTunnels
+}
+
+// SetReconnectBackoff records the delay before the next reconnect attempt.
+func (r *Registry) SetReconnectBackoff(seconds float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reconnectBackoff = seconds
+}
+
+// IncActiveHTTPStreams marks the start of a proxied HttpRequestMessage.
+func (r *Registry) IncActiveHTTPStreams() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeHTTPStreams++
+}
+
+// DecActiveHTTPStreams marks the end of a proxied HttpRequestMessage.
+func (r *Registry) DecActiveHTTPStreams() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeHTTPStreams--
+}
+
+// IncActiveWSStreams marks the start of a relayed ws-* stream.
+func (r *Registry) IncActiveWSStreams() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeWSStreams++
+}
+
+// DecActiveWSStreams marks the end of a relayed ws-* stream.
+func (r *Registry) DecActiveWSStreams() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeWSStreams--
+}
+
+// AddBytesIn records n bytes of request body received from the tunnel.
+func (r *Registry) AddBytesIn(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytesIn += int64(n)
+}
+
+// AddBytesOut records n bytes of response body sent back through the tunnel.
+func (r *Registry) AddBytesOut(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytesOut += int64(n)
+}
+
+// ObserveRequestDuration records the end-to-end duration of one proxied
+// request, in seconds.
+func (r *Registry) ObserveRequestDuration(seconds float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requestDuration.observe(seconds)
+}
+
+// IncStatusCode records one proxied response with the given HTTP status
+// code.
+func (r *Registry) IncStatusCode(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statusCodes[code]++
+}
+
+// IncUnknownMessage records one text message whose type ParseTextMessage
+// didn't recognize.
+func (r *Registry) IncUnknownMessage() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unknownMessages++
+}
+
+// SetTunnelUp records whether subdomain's tunnel currently has an open
+// WebSocket connection to the given local port, for xpose_tunnel_up.
+func (r *Registry) SetTunnelUp(subdomain string, port int, up bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tunnelUp[subdomain] = tunnelUpState{port: port, up: up}
+}
+
+// IncRequestTotal records one proxied request completing for subdomain, for
+// xpose_requests_total{subdomain,method,status}.
+func (r *Registry) IncRequestTotal(subdomain, method string, status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requestsTotal[requestLabelKey{subdomain: subdomain, method: method, status: status}]++
+}
+
+// IncActiveStreams marks the start of one in-flight request on subdomain's
+// tunnel, for xpose_active_streams.
+func (r *Registry) IncActiveStreams(subdomain string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeStreams[subdomain]++
+}
+
+// DecActiveStreams marks the end of one in-flight request on subdomain's
+// tunnel.
+func (r *Registry) DecActiveStreams(subdomain string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activeStreams[subdomain]--
+}
+
+// IncReconnects records one reconnect attempt scheduled for subdomain's
+// tunnel, for xpose_reconnects_total.
+func (r *Registry) IncReconnects(subdomain string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reconnectsTotal[subdomain]++
+}
+
+// SetTunnelDeadline records when subdomain's current TTL grant expires, so
+// xpose_ttl_remaining_seconds can be computed at scrape time rather than
+// maintained by a background countdown.
+func (r *Registry) SetTunnelDeadline(subdomain string, deadline time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tunnelDeadline[subdomain] = deadline
+}
+
+// Render writes every metric in Prometheus text exposition format to w.
+func (r *Registry) Render(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := []string{
+		"# HELP xpose_connect_attempts_total Total WebSocket dial attempts across all tunnels.",
+		"# TYPE xpose_connect_attempts_total counter",
+		fmt.Sprintf("xpose_connect_attempts_total %d", r.connectAttempts),
+
+		"# HELP xpose_connected_tunnels Number of tunnels with an open WebSocket connection.",
+		"# TYPE xpose_connected_tunnels gauge",
+		fmt.Sprintf("xpose_connected_tunnels %d", r.connectedTunnels),
+
+		"# HELP xpose_authenticated_tunnels Number of tunnels that have received an auth-ack.",
+		"# TYPE xpose_authenticated_tunnels gauge",
+		fmt.Sprintf("xpose_authenticated_tunnels %d", r.authenticatedTunnels),
+
+		"# HELP xpose_reconnect_backoff_seconds Delay before the next scheduled reconnect attempt.",
+		"# TYPE xpose_reconnect_backoff_seconds gauge",
+		fmt.Sprintf("xpose_reconnect_backoff_seconds %g", r.reconnectBackoff),
+
+		"# HELP xpose_active_http_streams Number of HttpRequestMessage streams currently being proxied.",
+		"# TYPE xpose_active_http_streams gauge",
+		fmt.Sprintf("xpose_active_http_streams %d", r.activeHTTPStreams),
+
+		"# HELP xpose_active_ws_streams Number of relayed ws-* streams currently open.",
+		"# TYPE xpose_active_ws_streams gauge",
+		fmt.Sprintf("xpose_active_ws_streams %d", r.activeWSStreams),
+
+		"# HELP xpose_bytes_in_total Total request body bytes received from the tunnel.",
+		"# TYPE xpose_bytes_in_total counter",
+		fmt.Sprintf("xpose_bytes_in_total %d", r.bytesIn),
+
+		"# HELP xpose_bytes_out_total Total response body bytes sent back through the tunnel.",
+		"# TYPE xpose_bytes_out_total counter",
+		fmt.Sprintf("xpose_bytes_out_total %d", r.bytesOut),
+
+		"# HELP xpose_unknown_message_total Total text messages of an unrecognized type.",
+		"# TYPE xpose_unknown_message_total counter",
+		fmt.Sprintf("xpose_unknown_message_total %d", r.unknownMessages),
+	}
+
+	lines = append(lines, r.statusCodeLines()...)
+	lines = append(lines, requestDurationLines(r.requestDuration)...)
+	lines = append(lines, r.tunnelUpLines()...)
+	lines = append(lines, r.requestsTotalLines()...)
+	lines = append(lines, r.activeStreamsLines()...)
+	lines = append(lines, r.reconnectsTotalLines()...)
+	lines = append(lines, r.ttlRemainingLines()...)
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) statusCodeLines() []string {
+	codes := make([]int, 0, len(r.statusCodes))
+	for code := range r.statusCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	lines := []string{
+		"# HELP xpose_response_status_total Total proxied responses by HTTP status code.",
+		"# TYPE xpose_response_status_total counter",
+	}
+	for _, code := range codes {
+		lines = append(lines, fmt.Sprintf(`xpose_response_status_total{code="%d"} %d`, code, r.statusCodes[code]))
+	}
+	return lines
+}
+
+func requestDurationLines(h *histogram) []string {
+	lines := []string{
+		"# HELP xpose_request_duration_seconds Duration of proxied requests, from http-request to http-response-end.",
+		"# TYPE xpose_request_duration_seconds histogram",
+	}
+	// h.counts is already cumulative (observe bumps every bucket whose bound
+	// is >= the value), so each bucket is rendered as-is.
+	for i, bound := range h.upperBounds {
+		lines = append(lines, fmt.Sprintf(`xpose_request_duration_seconds_bucket{le="%g"} %d`, bound, h.counts[i]))
+	}
+	lines = append(lines, fmt.Sprintf(`xpose_request_duration_seconds_bucket{le="+Inf"} %d`, h.count))
+	lines = append(lines, fmt.Sprintf("xpose_request_duration_seconds_sum %g", h.sum))
+	lines = append(lines, fmt.Sprintf("xpose_request_duration_seconds_count %d", h.count))
+	return lines
+}
+
+func (r *Registry) tunnelUpLines() []string {
+	subdomains := make([]string, 0, len(r.tunnelUp))
+	for subdomain := range r.tunnelUp {
+		subdomains = append(subdomains, subdomain)
+	}
+	sort.Strings(subdomains)
+
+	lines := []string{
+		"# HELP xpose_tunnel_up Whether a tunnel's WebSocket connection is currently open (1) or not (0).",
+		"# TYPE xpose_tunnel_up gauge",
+	}
+	for _, subdomain := range subdomains {
+		state := r.tunnelUp[subdomain]
+		up := 0
+		if state.up {
+			up = 1
+		}
+		lines = append(lines, fmt.Sprintf(`xpose_tunnel_up{subdomain="%s",port="%d"} %d`, subdomain, state.port, up))
+	}
+	return lines
+}
+
+func (r *Registry) requestsTotalLines() []string {
+	keys := make([]requestLabelKey, 0, len(r.requestsTotal))
+	for key := range r.requestsTotal {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].subdomain != keys[j].subdomain {
+			return keys[i].subdomain < keys[j].subdomain
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	lines := []string{
+		"# HELP xpose_requests_total Total proxied requests by tunnel, HTTP method, and response status.",
+		"# TYPE xpose_requests_total counter",
+	}
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf(`xpose_requests_total{subdomain="%s",method="%s",status="%d"} %d`,
+			key.subdomain, key.method, key.status, r.requestsTotal[key]))
+	}
+	return lines
+}
+
+func (r *Registry) activeStreamsLines() []string {
+	subdomains := make([]string, 0, len(r.activeStreams))
+	for subdomain := range r.activeStreams {
+		subdomains = append(subdomains, subdomain)
+	}
+	sort.Strings(subdomains)
+
+	lines := []string{
+		"# HELP xpose_active_streams Number of in-flight proxied requests per tunnel.",
+		"# TYPE xpose_active_streams gauge",
+	}
+	for _, subdomain := range subdomains {
+		lines = append(lines, fmt.Sprintf(`xpose_active_streams{subdomain="%s"} %d`, subdomain, r.activeStreams[subdomain]))
+	}
+	return lines
+}
+
+func (r *Registry) reconnectsTotalLines() []string {
+	subdomains := make([]string, 0, len(r.reconnectsTotal))
+	for subdomain := range r.reconnectsTotal {
+		subdomains = append(subdomains, subdomain)
+	}
+	sort.Strings(subdomains)
+
+	lines := []string{
+		"# HELP xpose_reconnects_total Total reconnect attempts scheduled per tunnel.",
+		"# TYPE xpose_reconnects_total counter",
+	}
+	for _, subdomain := range subdomains {
+		lines = append(lines, fmt.Sprintf(`xpose_reconnects_total{subdomain="%s"} %d`, subdomain, r.reconnectsTotal[subdomain]))
+	}
+	return lines
+}
+
+func (r *Registry) ttlRemainingLines() []string {
+	subdomains := make([]string, 0, len(r.tunnelDeadline))
+	for subdomain := range r.tunnelDeadline {
+		subdomains = append(subdomains, subdomain)
+	}
+	sort.Strings(subdomains)
+
+	lines := []string{
+		"# HELP xpose_ttl_remaining_seconds Seconds remaining before the tunnel's current TTL grant expires.",
+		"# TYPE xpose_ttl_remaining_seconds gauge",
+	}
+	now := time.Now()
+	for _, subdomain := range subdomains {
+		remaining := r.tunnelDeadline[subdomain].Sub(now).Seconds()
+		if remaining < 0 {
+			remaining = 0
+		}
+		lines = append(lines, fmt.Sprintf(`xpose_ttl_remaining_seconds{subdomain="%s"} %g`, subdomain, remaining))
+	}
+	return lines
+}
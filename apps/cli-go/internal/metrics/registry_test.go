@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_RenderRendersCountersGaugesAndHistogram(t *testing.T) {
+	r := NewRegistry()
+
+	r.IncConnectAttempts()
+	r.IncConnectAttempts()
+	r.IncConnectedTunnels()
+	r.IncAuthenticatedTunnels()
+	r.SetReconnectBackoff(1.5)
+	r.IncActiveHTTPStreams()
+	r.IncActiveWSStreams()
+	r.AddBytesIn(100)
+	r.AddBytesOut(200)
+	r.ObserveRequestDuration(0.02)
+	r.IncStatusCode(200)
+	r.IncStatusCode(200)
+	r.IncStatusCode(404)
+	r.IncUnknownMessage()
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Render(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "xpose_connect_attempts_total 2")
+	assert.Contains(t, out, "xpose_connected_tunnels 1")
+	assert.Contains(t, out, "xpose_authenticated_tunnels 1")
+	assert.Contains(t, out, "xpose_reconnect_backoff_seconds 1.5")
+	assert.Contains(t, out, "xpose_active_http_streams 1")
+	assert.Contains(t, out, "xpose_active_ws_streams 1")
+	assert.Contains(t, out, "xpose_bytes_in_total 100")
+	assert.Contains(t, out, "xpose_bytes_out_total 200")
+	assert.Contains(t, out, `xpose_response_status_total{code="200"} 2`)
+	assert.Contains(t, out, `xpose_response_status_total{code="404"} 1`)
+	assert.Contains(t, out, "xpose_unknown_message_total 1")
+	assert.Contains(t, out, `xpose_request_duration_seconds_bucket{le="0.025"} 1`)
+	assert.Contains(t, out, "xpose_request_duration_seconds_count 1")
+}
+
+func TestRegistry_DecCountersUndoIncrements(t *testing.T) {
+	r := NewRegistry()
+
+	r.IncConnectedTunnels()
+	r.IncConnectedTunnels()
+	r.DecConnectedTunnels()
+	assert.Equal(t, int64(1), r.ConnectedTunnels())
+
+	r.IncAuthenticatedTunnels()
+	r.DecAuthenticatedTunnels()
+	assert.Equal(t, int64(0), r.AuthenticatedTunnels())
+}
+
+func TestRegistry_RenderRendersPerTunnelLabeledMetrics(t *testing.T) {
+	r := NewRegistry()
+
+	r.SetTunnelUp("app", 3000, true)
+	r.IncRequestTotal("app", "GET", 200)
+	r.IncRequestTotal("app", "GET", 200)
+	r.IncActiveStreams("app")
+	r.IncReconnects("app")
+	r.SetTunnelDeadline("app", time.Now().Add(time.Hour))
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Render(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, `xpose_tunnel_up{subdomain="app",port="3000"} 1`)
+	assert.Contains(t, out, `xpose_requests_total{subdomain="app",method="GET",status="200"} 2`)
+	assert.Contains(t, out, `xpose_active_streams{subdomain="app"} 1`)
+	assert.Contains(t, out, `xpose_reconnects_total{subdomain="app"} 1`)
+	assert.Contains(t, out, `xpose_ttl_remaining_seconds{subdomain="app"}`)
+}
+
+func TestRegistry_SetTunnelUpFalseAfterDisconnect(t *testing.T) {
+	r := NewRegistry()
+
+	r.SetTunnelUp("app", 3000, true)
+	r.SetTunnelUp("app", 3000, false)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Render(&buf))
+	assert.Contains(t, buf.String(), `xpose_tunnel_up{subdomain="app",port="3000"} 0`)
+}
+
+func TestRegistry_TTLRemainingClampsAtZeroPastDeadline(t *testing.T) {
+	r := NewRegistry()
+	r.SetTunnelDeadline("app", time.Now().Add(-time.Minute))
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Render(&buf))
+	assert.Contains(t, buf.String(), `xpose_ttl_remaining_seconds{subdomain="app"} 0`)
+}
+
+func TestHistogram_ObserveBucketsCumulatively(t *testing.T) {
+	h := newHistogram([]float64{0.1, 0.5, 1})
+
+	h.observe(0.05)
+	h.observe(0.3)
+	h.observe(2)
+
+	assert.Equal(t, uint64(1), h.counts[0]) // <= 0.1
+	assert.Equal(t, uint64(2), h.counts[1]) // <= 0.5
+	assert.Equal(t, uint64(2), h.counts[2]) // <= 1
+	assert.Equal(t, uint64(3), h.count)
+}
@@ -17,8 +17,50 @@ const (
 	DefaultTTLSeconds          = 14_400
 	MaxTTLSeconds              = 86_400
 	TunnelConnectPath          = "/_tunnel/connect"
+	AuthorizePath              = "/_tunnel/authorize"
+	ReauthorizeIntervalSeconds = 300
 	DefaultPublicDomain        = "xpose.dev"
 	PingText                   = "ping"
 	PongText                   = "pong"
 	SessionResumeWindowSeconds = 600
+
+	// ProtocolVersion is the highest binary-framing protocol version this
+	// client speaks. It's sent in AuthMessage and echoed back (possibly
+	// lower, for an older server) in AuthAckMessage; a zero/absent version
+	// on either side means "legacy framing", i.e. EncodeBinaryFrame with no
+	// sequencing, flags, or flow control.
+	ProtocolVersion = 2
+
+	// DefaultFrameMTU bounds how large a single sub-frame's payload may be
+	// before Framer splits it across multiple frames.
+	DefaultFrameMTU = 32 * 1024
+
+	// DefaultWindowSizeBytes is the initial flow-control credit granted to
+	// a stream under protocol version 2+.
+	DefaultWindowSizeBytes = 256 * 1024
+
+	// DefaultRequestWindowSizeBytes is the initial flow-control credit
+	// granted to an inbound HTTP request body stream, replenished via
+	// HttpFlowControlMessage as the local server consumes buffered bytes.
+	// Unlike DefaultWindowSizeBytes it applies regardless of negotiated
+	// protocol version, since request body credit travels as a text
+	// message rather than a binary frame flag. It bounds how much of a
+	// streamed request body the client buffers at once, independent of
+	// the (possibly much larger) MaxBodySizeBytes ceiling on total size.
+	DefaultRequestWindowSizeBytes = 1 * 1024 * 1024
+
+	// StatusProtocolVersionUnsupported is the ErrorMessage status the server
+	// returns when it and the client share no usable protocol version,
+	// instead of disconnecting without explanation.
+	StatusProtocolVersionUnsupported = 426
+
+	// DefaultHeartbeatIntervalSeconds is how often the client sends an
+	// application-layer PingMsg to detect a silently half-open connection,
+	// absent ClientOptions.HeartbeatInterval.
+	DefaultHeartbeatIntervalSeconds = 20
+
+	// DefaultHeartbeatMissThreshold is how many consecutive heartbeat pings
+	// may go unanswered before the client treats the peer as dead and forces
+	// a reconnect, absent ClientOptions.HeartbeatMissThreshold.
+	DefaultHeartbeatMissThreshold = 3
 )
@@ -0,0 +1,165 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// FrameFlags is a bitmask of frame-level control signals carried in a
+// Frame's header.
+type FrameFlags uint8
+
+const (
+	// FlagFin marks the final frame of a request/response body.
+	FlagFin FrameFlags = 1 << iota
+	// FlagWindowUpdate marks a frame that carries a flow-control credit
+	// increment (see Window) rather than body bytes.
+	FlagWindowUpdate
+	// FlagReset aborts a stream; the receiver should discard any buffered
+	// data for the request ID and stop sending further frames for it.
+	FlagReset
+)
+
+// frameHeaderSize is the fixed header every Frame carries: requestID
+// (RequestIDLength) + seq (4 bytes) + flags (1 byte) + payloadLen (4 bytes).
+const frameHeaderSize = RequestIDLength + 4 + 1 + 4
+
+// Frame is a single length-prefixed, sequenced sub-frame of a binary
+// message: [requestID][seq][flags][payloadLen][payload]. Unlike the legacy
+// bare requestID+body framing (EncodeBinaryFrame), a Frame carries a
+// sequence number so large payloads can be split across multiple frames,
+// and flags so either side can signal FIN/RESET/WINDOW_UPDATE without a
+// separate text message.
+type Frame struct {
+	RequestID string
+	Seq       uint32
+	Flags     FrameFlags
+	Payload   []byte
+}
+
+// EncodeFrame serializes a single frame.
+func EncodeFrame(f Frame) []byte {
+	out := make([]byte, frameHeaderSize+len(f.Payload))
+	copy(out[:RequestIDLength], f.RequestID)
+	binary.BigEndian.PutUint32(out[RequestIDLength:], f.Seq)
+	out[RequestIDLength+4] = byte(f.Flags)
+	binary.BigEndian.PutUint32(out[RequestIDLength+5:], uint32(len(f.Payload)))
+	copy(out[frameHeaderSize:], f.Payload)
+	return out
+}
+
+// DecodeFrame decodes a single frame from the start of data and returns the
+// number of bytes it consumed, so callers can decode several frames packed
+// into one WebSocket message.
+func DecodeFrame(data []byte) (Frame, int, error) {
+	if len(data) < frameHeaderSize {
+		return Frame{}, 0, fmt.Errorf("frame header too short: %d bytes", len(data))
+	}
+	requestID := string(data[:RequestIDLength])
+	seq := binary.BigEndian.Uint32(data[RequestIDLength:])
+	flags := FrameFlags(data[RequestIDLength+4])
+	payloadLen := binary.BigEndian.Uint32(data[RequestIDLength+5:])
+	total := frameHeaderSize + int(payloadLen)
+	if len(data) < total {
+		return Frame{}, 0, fmt.Errorf("frame payload truncated: want %d bytes, have %d", total, len(data))
+	}
+	payload := data[frameHeaderSize:total]
+	return Frame{RequestID: requestID, Seq: seq, Flags: flags, Payload: payload}, total, nil
+}
+
+// DecodeFrames decodes every frame packed into a single binary message.
+func DecodeFrames(data []byte) ([]Frame, error) {
+	var frames []Frame
+	for len(data) > 0 {
+		f, n, err := DecodeFrame(data)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, f)
+		data = data[n:]
+	}
+	return frames, nil
+}
+
+// NewWindowUpdateFrame builds a frame granting the peer credit additional
+// bytes of send window for requestID.
+func NewWindowUpdateFrame(requestID string, credit uint32) Frame {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, credit)
+	return Frame{RequestID: requestID, Flags: FlagWindowUpdate, Payload: payload}
+}
+
+// WindowUpdateCredit extracts the credit increment from a WINDOW_UPDATE
+// frame's payload.
+func WindowUpdateCredit(f Frame) (uint32, error) {
+	if len(f.Payload) != 4 {
+		return 0, fmt.Errorf("malformed window update payload: %d bytes", len(f.Payload))
+	}
+	return binary.BigEndian.Uint32(f.Payload), nil
+}
+
+// NewResetFrame builds a frame that aborts requestID's stream.
+func NewResetFrame(requestID string) Frame {
+	return Frame{RequestID: requestID, Flags: FlagReset}
+}
+
+// Framer splits payloads into MTU-sized frames and packs frames destined
+// for the same binary message together, so one oversized chunk can't blow
+// past a WebSocket/proxy frame size limit and small chunks don't each pay
+// for a full message.
+type Framer struct {
+	MTU int
+}
+
+// NewFramer creates a Framer using mtu, or DefaultFrameMTU if mtu <= 0.
+func NewFramer(mtu int) *Framer {
+	if mtu <= 0 {
+		mtu = DefaultFrameMTU
+	}
+	return &Framer{MTU: mtu}
+}
+
+// Split breaks payload into one or more frames no larger than the Framer's
+// MTU, numbered sequentially from startSeq and tagged with FlagFin on the
+// last frame when fin is true. It returns the frames and the next sequence
+// number to use for this request ID.
+func (fr *Framer) Split(requestID string, payload []byte, startSeq uint32, fin bool) ([]Frame, uint32) {
+	if len(payload) == 0 {
+		if !fin {
+			return nil, startSeq
+		}
+		return []Frame{{RequestID: requestID, Seq: startSeq, Flags: FlagFin}}, startSeq + 1
+	}
+
+	mtu := fr.MTU
+	if mtu <= 0 {
+		mtu = DefaultFrameMTU
+	}
+
+	var frames []Frame
+	seq := startSeq
+	for offset := 0; offset < len(payload); offset += mtu {
+		end := offset + mtu
+		if end > len(payload) {
+			end = len(payload)
+		}
+		var flags FrameFlags
+		if fin && end == len(payload) {
+			flags = FlagFin
+		}
+		frames = append(frames, Frame{RequestID: requestID, Seq: seq, Flags: flags, Payload: payload[offset:end]})
+		seq++
+	}
+	return frames, seq
+}
+
+// Pack encodes and concatenates frames into a single binary message
+// payload, so multiple sub-frames can share one WebSocket message instead
+// of one frame per message.
+func (fr *Framer) Pack(frames []Frame) []byte {
+	var out []byte
+	for _, f := range frames {
+		out = append(out, EncodeFrame(f)...)
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFrameRoundtrip(t *testing.T) {
+	reqID := "abcdef123456"
+	f := Frame{RequestID: reqID, Seq: 7, Flags: FlagFin, Payload: []byte("hello")}
+
+	data := EncodeFrame(f)
+	got, n, err := DecodeFrame(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != len(data) {
+		t.Errorf("consumed: got %d, want %d", n, len(data))
+	}
+	if got.RequestID != reqID {
+		t.Errorf("requestID: got %q, want %q", got.RequestID, reqID)
+	}
+	if got.Seq != 7 {
+		t.Errorf("seq: got %d, want 7", got.Seq)
+	}
+	if got.Flags != FlagFin {
+		t.Errorf("flags: got %v, want %v", got.Flags, FlagFin)
+	}
+	if !bytes.Equal(got.Payload, f.Payload) {
+		t.Errorf("payload: got %q, want %q", got.Payload, f.Payload)
+	}
+}
+
+func TestDecodeFrame_TooShort(t *testing.T) {
+	if _, _, err := DecodeFrame([]byte("short")); err == nil {
+		t.Fatal("expected error for truncated header")
+	}
+}
+
+func TestDecodeFrame_TruncatedPayload(t *testing.T) {
+	f := Frame{RequestID: "abcdef123456", Payload: []byte("hello world")}
+	data := EncodeFrame(f)
+
+	if _, _, err := DecodeFrame(data[:len(data)-3]); err == nil {
+		t.Fatal("expected error for truncated payload")
+	}
+}
+
+func TestDecodeFrames_PacksMultiple(t *testing.T) {
+	reqID := "abcdef123456"
+	f1 := Frame{RequestID: reqID, Seq: 0, Payload: []byte("one")}
+	f2 := Frame{RequestID: reqID, Seq: 1, Flags: FlagFin, Payload: []byte("two")}
+
+	packed := append(EncodeFrame(f1), EncodeFrame(f2)...)
+
+	frames, err := DecodeFrames(packed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if string(frames[0].Payload) != "one" || string(frames[1].Payload) != "two" {
+		t.Errorf("unexpected payloads: %q, %q", frames[0].Payload, frames[1].Payload)
+	}
+	if frames[1].Flags != FlagFin {
+		t.Errorf("expected second frame to carry FlagFin")
+	}
+}
+
+func TestWindowUpdateFrameRoundtrip(t *testing.T) {
+	f := NewWindowUpdateFrame("abcdef123456", 65536)
+	if f.Flags != FlagWindowUpdate {
+		t.Fatalf("expected FlagWindowUpdate")
+	}
+
+	credit, err := WindowUpdateCredit(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if credit != 65536 {
+		t.Errorf("credit: got %d, want 65536", credit)
+	}
+}
+
+func TestWindowUpdateCredit_MalformedPayload(t *testing.T) {
+	f := Frame{Payload: []byte{0x01}}
+	if _, err := WindowUpdateCredit(f); err == nil {
+		t.Fatal("expected error for malformed payload")
+	}
+}
+
+func TestFramerSplit_SmallPayloadSingleFrame(t *testing.T) {
+	fr := NewFramer(1024)
+	frames, next := fr.Split("abcdef123456", []byte("hello"), 0, true)
+
+	if len(frames) != 1 {
+		t.Fatalf("got %d frames, want 1", len(frames))
+	}
+	if frames[0].Flags != FlagFin {
+		t.Errorf("expected single frame to carry FlagFin")
+	}
+	if next != 1 {
+		t.Errorf("next seq: got %d, want 1", next)
+	}
+}
+
+func TestFramerSplit_LargePayloadMultipleFrames(t *testing.T) {
+	fr := NewFramer(4)
+	payload := []byte("0123456789") // 10 bytes / MTU 4 => 3 frames
+
+	frames, next := fr.Split("abcdef123456", payload, 10, true)
+
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(frames))
+	}
+	if frames[0].Seq != 10 || frames[1].Seq != 11 || frames[2].Seq != 12 {
+		t.Errorf("unexpected sequence numbers: %d, %d, %d", frames[0].Seq, frames[1].Seq, frames[2].Seq)
+	}
+	for i, f := range frames[:len(frames)-1] {
+		if f.Flags&FlagFin != 0 {
+			t.Errorf("frame %d should not carry FlagFin", i)
+		}
+	}
+	if frames[len(frames)-1].Flags&FlagFin == 0 {
+		t.Error("last frame should carry FlagFin")
+	}
+
+	var reassembled []byte
+	for _, f := range frames {
+		reassembled = append(reassembled, f.Payload...)
+	}
+	if !bytes.Equal(reassembled, payload) {
+		t.Errorf("reassembled payload: got %q, want %q", reassembled, payload)
+	}
+	if next != 13 {
+		t.Errorf("next seq: got %d, want 13", next)
+	}
+}
+
+func TestFramerPack_ConcatenatesEncodedFrames(t *testing.T) {
+	fr := NewFramer(1024)
+	frames, _ := fr.Split("abcdef123456", []byte("abc"), 0, false)
+	frames = append(frames, NewWindowUpdateFrame("abcdef123456", 10))
+
+	packed := fr.Pack(frames)
+	decoded, err := DecodeFrames(packed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("got %d frames, want 2", len(decoded))
+	}
+}
@@ -10,22 +10,94 @@ type Envelope struct {
 }
 
 // AuthMessage is sent by the client to authenticate a tunnel session.
+// ReconnectToken, when present, lets the server resume the session by
+// validating a server-signed token in preference to the bare subdomain.
+// ProtocolVersion and Capabilities let an older client or server keep
+// working with a newer peer: the JSON field name stays "version" for
+// compatibility, and a peer that omits it is treated as v0 with no
+// capabilities.
 type AuthMessage struct {
-	Type      string `json:"type"`
-	Subdomain string `json:"subdomain"`
-	TTL       int    `json:"ttl,omitempty"`
-	SessionID string `json:"sessionId,omitempty"`
+	Type              string   `json:"type"`
+	Subdomain         string   `json:"subdomain"`
+	TTL               int      `json:"ttl,omitempty"`
+	SessionID         string   `json:"sessionId,omitempty"`
+	Mode              string   `json:"mode,omitempty"` // "tcp" for raw TCP tunnels; omitted for HTTP
+	ReconnectToken    string   `json:"reconnectToken,omitempty"`
+	ProtocolVersion   int      `json:"version,omitempty"`           // highest protocol version the client speaks
+	Capabilities      []string `json:"capabilities,omitempty"`      // optional features the client supports, e.g. CapabilityBinaryFramingV2
+	AcceptedEncodings []string `json:"acceptedEncodings,omitempty"` // body-compression codecs the client can decode, e.g. "gzip"
+	CustomDomain      string   `json:"customDomain,omitempty"`      // a domain the client owns, in place of Subdomain.Domain; requires TLSMode != "none"
+	TLSMode           string   `json:"tlsMode,omitempty"`           // TLSModeAuto, TLSModeManual, or TLSModeNone; empty is treated as TLSModeNone
 }
 
 // AuthAckMessage is sent by the server to acknowledge a successful authentication.
+// ReconnectToken is rotated on every ack; the client must persist the latest
+// value and present it on the next resume instead of the one it connected
+// with. Capabilities is the intersection of what the client advertised and
+// what the server supports; the client must not use a capability absent
+// from this list even if it offered it.
 type AuthAckMessage struct {
-	Type             string `json:"type"`
-	Subdomain        string `json:"subdomain"`
-	URL              string `json:"url"`
-	TTL              int    `json:"ttl"`
-	RemainingTTL     int    `json:"remainingTtl"`
-	SessionID        string `json:"sessionId"`
-	MaxBodySizeBytes int    `json:"maxBodySizeBytes"`
+	Type               string   `json:"type"`
+	Subdomain          string   `json:"subdomain"`
+	URL                string   `json:"url"`
+	TTL                int      `json:"ttl"`
+	RemainingTTL       int      `json:"remainingTtl"`
+	SessionID          string   `json:"sessionId"`
+	MaxBodySizeBytes   int      `json:"maxBodySizeBytes"`
+	SessionFingerprint string   `json:"sessionFingerprint,omitempty"`
+	ReconnectToken     string   `json:"reconnectToken,omitempty"`
+	ProtocolVersion    int      `json:"version,omitempty"` // negotiated protocol version; 0 means legacy framing
+	Capabilities       []string `json:"capabilities,omitempty"`
+	BodyEncoding       string   `json:"bodyEncoding,omitempty"`  // codec the server will use for compressed http-body-chunk payloads, e.g. "gzip"; empty means identity
+	ChallengeHost      string   `json:"challengeHost,omitempty"` // hostname the server is currently routing this tunnel's public traffic through, for the client's http-01 challenge to target
+}
+
+// TLS modes recognized in AuthMessage.TLSMode.
+const (
+	TLSModeAuto   = "auto"   // the client drives ACME itself and serves its own certificate
+	TLSModeManual = "manual" // the client supplies a certificate out of band; the server just routes CustomDomain to this tunnel
+	TLSModeNone   = "none"   // no custom domain; the default *.xpose.dev subdomain and server-terminated TLS apply
+)
+
+// Capability names recognized in AuthMessage.Capabilities and
+// AuthAckMessage.Capabilities.
+const (
+	CapabilityPermessageDeflate     = "permessage-deflate"
+	CapabilityBinaryFramingV2       = "binary-framing-v2"
+	CapabilityReconnectToken        = "reconnect-token"
+	CapabilityH2RequestMultiplexing = "h2-request-multiplexing"
+	CapabilitySessionResume         = "session-resume"
+)
+
+// ClientCapabilities lists the capabilities this build of the CLI supports
+// and advertises in AuthMessage.Capabilities. The server echoes back the
+// subset it also supports in AuthAckMessage.Capabilities; callers should key
+// feature selection (e.g. compression, framer variant) off the latter, not
+// this list.
+var ClientCapabilities = []string{CapabilityBinaryFramingV2, CapabilityReconnectToken, CapabilitySessionResume}
+
+// ClientAcceptedEncodings lists the body-compression codecs this build of
+// the CLI can decode, advertised in AuthMessage.AcceptedEncodings. The
+// server picks one (or none) and echoes it back in
+// AuthAckMessage.BodyEncoding; callers should key compression behavior off
+// that echoed value, not this list.
+var ClientAcceptedEncodings = []string{"gzip"}
+
+// AuthorizeRequest is sent to the /authorize endpoint to periodically
+// re-validate a long-lived tunnel session.
+type AuthorizeRequest struct {
+	SessionID string `json:"sessionId"`
+	Subdomain string `json:"subdomain"`
+}
+
+// AuthorizeResponse is returned by the /authorize endpoint. SessionFingerprint
+// is opaque and is expected to stay stable across calls for the same
+// session; a change (or a 401/403 status) signals that credentials or
+// routing were revoked and the tunnel must be torn down and reconnected.
+type AuthorizeResponse struct {
+	TTL                int    `json:"ttl"`
+	URL                string `json:"url"`
+	SessionFingerprint string `json:"sessionFingerprint"`
 }
 
 // HttpRequestMessage represents an incoming HTTP request forwarded through the tunnel.
@@ -48,10 +120,16 @@ type HttpResponseMetaMessage struct {
 }
 
 // HttpBodyChunkMessage signals a body chunk transfer for a given request.
+// Encoding names the wire-level compression codec applied to the binary
+// frame(s) that follow (empty means identity, i.e. uncompressed); it's a
+// transport detail independent of any Content-Encoding header the
+// underlying HTTP request or response carries, which passes through
+// unmodified.
 type HttpBodyChunkMessage struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-	Done bool   `json:"done"`
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Done     bool   `json:"done"`
+	Encoding string `json:"encoding,omitempty"`
 }
 
 // HttpRequestEndMessage signals the end of an HTTP request body.
@@ -66,14 +144,55 @@ type HttpResponseEndMessage struct {
 	ID   string `json:"id"`
 }
 
-// PingMsg is a keep-alive ping message.
+// HttpCancelMessage aborts an in-flight HTTP request/response stream, e.g.
+// because the downstream client disconnected or the local server's
+// connection broke mid-response. Reason is a short, human-readable string
+// for logs and isn't interpreted by either side.
+type HttpCancelMessage struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// HttpBodyErrorMessage reports a fatal error for a specific request or
+// response body stream (e.g. exceeding MaxBodySizeBytes) in place of
+// silently dropping bytes and leaving the peer waiting. Retryable tells the
+// peer whether reissuing the same request could plausibly succeed (a
+// transient local read failure) versus one that will just hit the same
+// limit again (body too large).
+type HttpBodyErrorMessage struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// HttpFlowControlMessage grants the peer additional send credit for an HTTP
+// request body stream. It plays the same role as the binary-framing
+// WINDOW_UPDATE frame (see FlagWindowUpdate) but for the request direction,
+// where inbound body bytes travel as plain binary frames with no per-frame
+// header to carry flow control in, regardless of the negotiated protocol
+// version.
+type HttpFlowControlMessage struct {
+	Type            string `json:"type"`
+	ID              string `json:"id"`
+	WindowIncrement int    `json:"windowIncrement"`
+}
+
+// PingMsg is a keep-alive ping message. Seq is omitted (zero) for a
+// server-initiated ping, which the client always answers regardless of
+// sequence; the client's own heartbeat pings set it so the returned PongMsg
+// can be correlated back to a send time for RTT measurement.
 type PingMsg struct {
 	Type string `json:"type"`
+	Seq  uint64 `json:"seq,omitempty"`
 }
 
-// PongMsg is a keep-alive pong response message.
+// PongMsg is a keep-alive pong response message, echoing the Seq of the
+// PingMsg it answers.
 type PongMsg struct {
 	Type string `json:"type"`
+	Seq  uint64 `json:"seq,omitempty"`
 }
 
 // ErrorMessage is sent by the server to indicate an error.
@@ -84,6 +203,51 @@ type ErrorMessage struct {
 	Status    int    `json:"status,omitempty"`
 }
 
+// ResumeMessage is sent by the client right after an auth-ack confirms the
+// same SessionID it had before a reconnect, so the server knows this is a
+// continuation rather than a fresh session. LastAckedRequestSeq is the
+// highest response sequence number (see Client's internal resume buffer)
+// the client has locally recorded as sent, and only ever increases across
+// the life of a session.
+type ResumeMessage struct {
+	Type                string `json:"type"`
+	SessionID           string `json:"sessionId"`
+	LastAckedRequestSeq uint64 `json:"lastAckedRequestSeq"`
+}
+
+// ResumeAckMessage answers a ResumeMessage. ResumedRequestIDs lists the
+// request IDs the server is asking the client to replay its buffered
+// response for, because the server doesn't have a complete copy (e.g. the
+// connection dropped before it was fully delivered); an empty slice means
+// the server has everything and no replay is needed.
+type ResumeAckMessage struct {
+	Type              string   `json:"type"`
+	SessionID         string   `json:"sessionId"`
+	ResumedRequestIDs []string `json:"resumedRequestIds"`
+}
+
+// RouteConfigMessage is sent by the client right after authentication when
+// its tunnel fans out to more than one local backend, publishing the
+// prefix->target map so the edge can route incoming requests by
+// longest-prefix match before framing them to the client, instead of the
+// client having to accept and re-dispatch every request itself. A single
+// Host/Port tunnel (the common case) has nothing to publish and never
+// sends this message.
+type RouteConfigMessage struct {
+	Type   string        `json:"type"`
+	Routes []RouteTarget `json:"routes"`
+}
+
+// RouteTarget is one entry in RouteConfigMessage.Routes, mirroring
+// tunnel.Route without importing the tunnel package (protocol sits below it
+// in the dependency graph).
+type RouteTarget struct {
+	PathPrefix  string `json:"pathPrefix"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	StripPrefix bool   `json:"stripPrefix,omitempty"`
+}
+
 // ---- WebSocket relay messages ----
 
 // WsUpgradeMessage is sent by the server to ask the CLI to open a local WS connection.
@@ -118,6 +282,30 @@ type WsCloseMessage struct {
 	Reason   string `json:"reason"`
 }
 
+// ---- TCP relay messages ----
+
+// TcpOpenMessage is sent by the server to ask the CLI to open a local TCP
+// connection for a raw `xpose tcp` tunnel.
+type TcpOpenMessage struct {
+	Type       string `json:"type"`
+	StreamID   string `json:"streamId"`
+	RemoteAddr string `json:"remoteAddr"`
+}
+
+// TcpDataMessage signals a TCP data relay. The actual payload follows as a
+// binary frame using the standard binary encoding (streamId prefix + body).
+type TcpDataMessage struct {
+	Type     string `json:"type"`
+	StreamID string `json:"streamId"`
+}
+
+// TcpCloseMessage signals that one side of a relayed TCP stream has closed.
+type TcpCloseMessage struct {
+	Type     string `json:"type"`
+	StreamID string `json:"streamId"`
+	Reason   string `json:"reason,omitempty"`
+}
+
 // ParseTextMessage parses a raw JSON message into its concrete message struct.
 // It returns (nil, nil) for unknown message types.
 // It returns (nil, error) for malformed JSON.
@@ -177,6 +365,27 @@ func ParseTextMessage(raw []byte) (any, error) {
 		}
 		return &msg, nil
 
+	case "http-cancel":
+		var msg HttpCancelMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
+	case "http-body-error":
+		var msg HttpBodyErrorMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
+	case "http-flow-control":
+		var msg HttpFlowControlMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
 	case "ping":
 		var msg PingMsg
 		if err := json.Unmarshal(raw, &msg); err != nil {
@@ -191,6 +400,27 @@ func ParseTextMessage(raw []byte) (any, error) {
 		}
 		return &msg, nil
 
+	case "resume":
+		var msg ResumeMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
+	case "resume-ack":
+		var msg ResumeAckMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
+	case "route-config":
+		var msg RouteConfigMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
 	case "error":
 		var msg ErrorMessage
 		if err := json.Unmarshal(raw, &msg); err != nil {
@@ -226,6 +456,27 @@ func ParseTextMessage(raw []byte) (any, error) {
 		}
 		return &msg, nil
 
+	case "tcp-open":
+		var msg TcpOpenMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
+	case "tcp-data":
+		var msg TcpDataMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
+	case "tcp-close":
+		var msg TcpCloseMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, err
+		}
+		return &msg, nil
+
 	default:
 		return nil, nil
 	}
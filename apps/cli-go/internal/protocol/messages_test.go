@@ -42,6 +42,11 @@ func TestParseTextMessage(t *testing.T) {
 			input:    `{"type":"http-body-chunk","id":"req123456789","done":false}`,
 			wantType: "http-body-chunk",
 		},
+		{
+			name:     "valid http-body-chunk message with gzip encoding",
+			input:    `{"type":"http-body-chunk","id":"req123456789","done":false,"encoding":"gzip"}`,
+			wantType: "http-body-chunk",
+		},
 		{
 			name:     "valid http-request-end message",
 			input:    `{"type":"http-request-end","id":"req123456789"}`,
@@ -52,6 +57,21 @@ func TestParseTextMessage(t *testing.T) {
 			input:    `{"type":"http-response-end","id":"req123456789"}`,
 			wantType: "http-response-end",
 		},
+		{
+			name:     "valid http-cancel message",
+			input:    `{"type":"http-cancel","id":"req123456789","reason":"client disconnected"}`,
+			wantType: "http-cancel",
+		},
+		{
+			name:     "valid http-body-error message",
+			input:    `{"type":"http-body-error","id":"req123456789","message":"body too large","retryable":false}`,
+			wantType: "http-body-error",
+		},
+		{
+			name:     "valid http-flow-control message",
+			input:    `{"type":"http-flow-control","id":"req123456789","windowIncrement":65536}`,
+			wantType: "http-flow-control",
+		},
 		{
 			name:     "valid error message with optional fields",
 			input:    `{"type":"error","message":"not found","requestId":"req123456789","status":404}`,
@@ -72,6 +92,26 @@ func TestParseTextMessage(t *testing.T) {
 			input:    `{"type":"pong"}`,
 			wantType: "pong",
 		},
+		{
+			name:     "valid resume message",
+			input:    `{"type":"resume","sessionId":"sess-001","lastAckedRequestSeq":42}`,
+			wantType: "resume",
+		},
+		{
+			name:     "valid resume-ack message with no replays",
+			input:    `{"type":"resume-ack","sessionId":"sess-001","resumedRequestIds":[]}`,
+			wantType: "resume-ack",
+		},
+		{
+			name:     "valid resume-ack message with replays",
+			input:    `{"type":"resume-ack","sessionId":"sess-001","resumedRequestIds":["req-1","req-2"]}`,
+			wantType: "resume-ack",
+		},
+		{
+			name:     "valid route-config message",
+			input:    `{"type":"route-config","routes":[{"pathPrefix":"/api","host":"localhost","port":8080},{"pathPrefix":"","host":"localhost","port":3000}]}`,
+			wantType: "route-config",
+		},
 		{
 			name:    "invalid JSON returns error",
 			input:   `{not valid json`,
@@ -161,6 +201,9 @@ func TestParseTextMessage(t *testing.T) {
 				if m.Done {
 					t.Errorf("expected done=false")
 				}
+				if tt.name == "valid http-body-chunk message with gzip encoding" && m.Encoding != "gzip" {
+					t.Errorf("expected encoding %q, got %q", "gzip", m.Encoding)
+				}
 			case "http-request-end":
 				_, ok := msg.(*HttpRequestEndMessage)
 				if !ok {
@@ -171,6 +214,30 @@ func TestParseTextMessage(t *testing.T) {
 				if !ok {
 					t.Fatalf("expected *HttpResponseEndMessage, got %T", msg)
 				}
+			case "http-cancel":
+				m, ok := msg.(*HttpCancelMessage)
+				if !ok {
+					t.Fatalf("expected *HttpCancelMessage, got %T", msg)
+				}
+				if m.Reason == "" {
+					t.Errorf("expected non-empty reason")
+				}
+			case "http-body-error":
+				m, ok := msg.(*HttpBodyErrorMessage)
+				if !ok {
+					t.Fatalf("expected *HttpBodyErrorMessage, got %T", msg)
+				}
+				if m.Retryable {
+					t.Errorf("expected retryable=false")
+				}
+			case "http-flow-control":
+				m, ok := msg.(*HttpFlowControlMessage)
+				if !ok {
+					t.Fatalf("expected *HttpFlowControlMessage, got %T", msg)
+				}
+				if m.WindowIncrement != 65536 {
+					t.Errorf("expected windowIncrement 65536, got %d", m.WindowIncrement)
+				}
 			case "error":
 				m, ok := msg.(*ErrorMessage)
 				if !ok {
@@ -189,6 +256,19 @@ func TestParseTextMessage(t *testing.T) {
 				if !ok {
 					t.Fatalf("expected *PongMsg, got %T", msg)
 				}
+			case "resume":
+				m, ok := msg.(*ResumeMessage)
+				if !ok {
+					t.Fatalf("expected *ResumeMessage, got %T", msg)
+				}
+				if m.SessionID != "sess-001" {
+					t.Errorf("expected sessionId %q, got %q", "sess-001", m.SessionID)
+				}
+			case "resume-ack":
+				_, ok := msg.(*ResumeAckMessage)
+				if !ok {
+					t.Fatalf("expected *ResumeAckMessage, got %T", msg)
+				}
 			}
 		})
 	}
@@ -215,6 +295,39 @@ func TestParseAuthMessageFields(t *testing.T) {
 	}
 }
 
+func TestParseAuthMessageCustomDomainFields(t *testing.T) {
+	raw := []byte(`{"type":"auth","subdomain":"mysubdomain1","customDomain":"tunnel.example.com","tlsMode":"auto"}`)
+	msg, err := ParseTextMessage(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auth, ok := msg.(*AuthMessage)
+	if !ok {
+		t.Fatalf("expected *AuthMessage, got %T", msg)
+	}
+	if auth.CustomDomain != "tunnel.example.com" {
+		t.Errorf("customDomain: got %q, want %q", auth.CustomDomain, "tunnel.example.com")
+	}
+	if auth.TLSMode != TLSModeAuto {
+		t.Errorf("tlsMode: got %q, want %q", auth.TLSMode, TLSModeAuto)
+	}
+}
+
+func TestParseAuthAckMessageChallengeHost(t *testing.T) {
+	raw := []byte(`{"type":"auth-ack","subdomain":"mysubdomain1","url":"https://mysubdomain1.xpose.dev","ttl":7200,"remainingTtl":7200,"sessionId":"sess-abc","maxBodySizeBytes":1048576,"challengeHost":"mysubdomain1.xpose.dev"}`)
+	msg, err := ParseTextMessage(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ack, ok := msg.(*AuthAckMessage)
+	if !ok {
+		t.Fatalf("expected *AuthAckMessage, got %T", msg)
+	}
+	if ack.ChallengeHost != "mysubdomain1.xpose.dev" {
+		t.Errorf("challengeHost: got %q, want %q", ack.ChallengeHost, "mysubdomain1.xpose.dev")
+	}
+}
+
 func TestParseErrorMessageOptionalFields(t *testing.T) {
 	raw := []byte(`{"type":"error","message":"timeout","requestId":"req-xyz","status":408}`)
 	msg, err := ParseTextMessage(raw)
@@ -233,6 +346,54 @@ func TestParseErrorMessageOptionalFields(t *testing.T) {
 	}
 }
 
+func TestParseTcpOpenMessage(t *testing.T) {
+	raw := []byte(`{"type":"tcp-open","streamId":"stream-001","remoteAddr":"203.0.113.5:51342"}`)
+	msg, err := ParseTextMessage(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	open, ok := msg.(*TcpOpenMessage)
+	if !ok {
+		t.Fatalf("expected *TcpOpenMessage, got %T", msg)
+	}
+	if open.StreamID != "stream-001" {
+		t.Errorf("streamId: got %q, want %q", open.StreamID, "stream-001")
+	}
+	if open.RemoteAddr != "203.0.113.5:51342" {
+		t.Errorf("remoteAddr: got %q, want %q", open.RemoteAddr, "203.0.113.5:51342")
+	}
+}
+
+func TestParseTcpDataMessage(t *testing.T) {
+	raw := []byte(`{"type":"tcp-data","streamId":"stream-001"}`)
+	msg, err := ParseTextMessage(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, ok := msg.(*TcpDataMessage)
+	if !ok {
+		t.Fatalf("expected *TcpDataMessage, got %T", msg)
+	}
+	if data.StreamID != "stream-001" {
+		t.Errorf("streamId: got %q, want %q", data.StreamID, "stream-001")
+	}
+}
+
+func TestParseTcpCloseMessage(t *testing.T) {
+	raw := []byte(`{"type":"tcp-close","streamId":"stream-001","reason":"peer reset"}`)
+	msg, err := ParseTextMessage(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closeMsg, ok := msg.(*TcpCloseMessage)
+	if !ok {
+		t.Fatalf("expected *TcpCloseMessage, got %T", msg)
+	}
+	if closeMsg.Reason != "peer reset" {
+		t.Errorf("reason: got %q, want %q", closeMsg.Reason, "peer reset")
+	}
+}
+
 func TestIsTunnelMessage(t *testing.T) {
 	tests := []struct {
 		name string
@@ -51,6 +51,28 @@ func BuildCustomSubdomain(prefix string) string {
 	return cleaned + "-" + suffix
 }
 
+// SubdomainGenerator produces a subdomain candidate for a new tunnel.
+// RandomGenerator, WordListGenerator, and CustomGenerator are the built-ins;
+// the CLI selects among them via --subdomain-style.
+type SubdomainGenerator interface {
+	Generate() string
+}
+
+// RandomGenerator produces a fully random SubdomainLength-character
+// subdomain, via GenerateSubdomainID.
+type RandomGenerator struct{}
+
+func (RandomGenerator) Generate() string { return GenerateSubdomainID() }
+
+// CustomGenerator produces a subdomain from a user-supplied prefix via
+// BuildCustomSubdomain, falling back to a random one if the prefix sanitizes
+// to nothing.
+type CustomGenerator struct {
+	Prefix string
+}
+
+func (g CustomGenerator) Generate() string { return BuildCustomSubdomain(g.Prefix) }
+
 // ValidateSubdomain checks whether a subdomain string is valid.
 // It returns (true, "") on success, or (false, reason) on failure.
 func ValidateSubdomain(subdomain string) (bool, string) {
@@ -87,6 +87,27 @@ func TestBuildCustomSubdomainUppercase(t *testing.T) {
 	}
 }
 
+func TestRandomGeneratorMatchesGenerateSubdomainID(t *testing.T) {
+	id := (RandomGenerator{}).Generate()
+	if len(id) != SubdomainLength {
+		t.Errorf("expected length %d, got %d: %q", SubdomainLength, len(id), id)
+	}
+}
+
+func TestCustomGeneratorSanitizesPrefix(t *testing.T) {
+	result := (CustomGenerator{Prefix: "My-App!"}).Generate()
+	if !strings.HasPrefix(result, "my-app-") {
+		t.Errorf("expected prefix %q, got %q", "my-app-", result)
+	}
+}
+
+func TestCustomGeneratorFallsBackToRandomOnEmptyPrefix(t *testing.T) {
+	result := (CustomGenerator{Prefix: "!!!"}).Generate()
+	if len(result) != SubdomainLength {
+		t.Errorf("expected fallback to random ID of length %d, got %d: %q", SubdomainLength, len(result), result)
+	}
+}
+
 func TestValidateSubdomainValid(t *testing.T) {
 	valid := []string{
 		"a",
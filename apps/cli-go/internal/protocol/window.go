@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"context"
+	"sync"
+)
+
+// Window implements per-stream, credit-based flow control: a sender must
+// hold enough credit before writing payload bytes, and a receiver grants
+// more credit (by sending a WINDOW_UPDATE frame, see NewWindowUpdateFrame)
+// as it drains whatever it's buffering those bytes into. This lets a slow
+// consumer on one side of the tunnel throttle a fast producer on the
+// other, rather than relying on WebSocket/TCP buffering alone.
+type Window struct {
+	mu        sync.Mutex
+	available int
+	signal    chan struct{}
+}
+
+// NewWindow creates a Window starting with initial bytes of credit.
+func NewWindow(initial int) *Window {
+	return &Window{available: initial, signal: make(chan struct{}, 1)}
+}
+
+// Consume blocks until n bytes of credit are available, deducts them, and
+// returns nil, or returns ctx.Err() if ctx is done first.
+func (w *Window) Consume(ctx context.Context, n int) error {
+	for {
+		w.mu.Lock()
+		if w.available >= n {
+			w.available -= n
+			w.mu.Unlock()
+			return nil
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.signal:
+		}
+	}
+}
+
+// Replenish adds n bytes of credit, e.g. on receiving a WINDOW_UPDATE frame.
+func (w *Window) Replenish(n int) {
+	w.mu.Lock()
+	w.available += n
+	w.mu.Unlock()
+
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Available returns the current credit.
+func (w *Window) Available() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.available
+}
@@ -0,0 +1,58 @@
+package protocol
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWindow_ConsumeWithinAvailableCredit(t *testing.T) {
+	w := NewWindow(100)
+
+	if err := w.Consume(context.Background(), 40); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := w.Available(); got != 60 {
+		t.Errorf("available: got %d, want 60", got)
+	}
+}
+
+func TestWindow_ConsumeBlocksUntilReplenished(t *testing.T) {
+	w := NewWindow(10)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Consume(context.Background(), 50)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Consume should have blocked with insufficient credit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	w.Replenish(40)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Consume did not unblock after Replenish")
+	}
+
+	if got := w.Available(); got != 0 {
+		t.Errorf("available: got %d, want 0", got)
+	}
+}
+
+func TestWindow_ConsumeReturnsErrorOnContextCancel(t *testing.T) {
+	w := NewWindow(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := w.Consume(ctx, 1); err == nil {
+		t.Fatal("expected context deadline error")
+	}
+}
@@ -0,0 +1,75 @@
+package protocol
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+//go:embed wordlists/adjectives.txt
+var rawAdjectives string
+
+//go:embed wordlists/nouns.txt
+var rawNouns string
+
+var (
+	adjectiveWords = filterWordList(rawAdjectives)
+	nounWords      = filterWordList(rawNouns)
+)
+
+// wordListTokenRe matches a single safe word-list entry: lowercase letters
+// and digits only. Hyphens are added when words are joined into a
+// subdomain, so an entry containing one would produce a run of consecutive
+// hyphens ValidateSubdomain rejects.
+var wordListTokenRe = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// filterWordList splits an embedded word list (one word per line) and drops
+// any entry that isn't plain lowercase-alphanumeric, so a stray typo or
+// blank line in the list can't slip an invalid subdomain character through.
+func filterWordList(raw string) []string {
+	lines := strings.Split(raw, "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		word := strings.TrimSpace(line)
+		if word != "" && wordListTokenRe.MatchString(word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// wordListMaxAttempts bounds how many adjective-adjective-noun combinations
+// WordListGenerator tries before giving up and falling back to
+// RandomGenerator.
+const wordListMaxAttempts = 10
+
+// WordListGenerator produces memorable subdomains like "brave-amber-otter"
+// by joining two adjectives and a noun from an embedded, curated word list -
+// easier to read aloud than RandomGenerator's opaque string, for tunnels
+// that get shared verbally.
+type WordListGenerator struct{}
+
+func (WordListGenerator) Generate() string {
+	for i := 0; i < wordListMaxAttempts; i++ {
+		candidate := strings.Join([]string{
+			randomWord(adjectiveWords),
+			randomWord(adjectiveWords),
+			randomWord(nounWords),
+		}, "-")
+		if ok, _ := ValidateSubdomain(candidate); ok && len(candidate) <= 63 {
+			return candidate
+		}
+	}
+	return RandomGenerator{}.Generate()
+}
+
+// randomWord picks a cryptographically random entry from words.
+func randomWord(words []string) string {
+	idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+	if err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+	return words[idx.Int64()]
+}
@@ -0,0 +1,61 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordListGeneratorProducesThreeHyphenatedWords(t *testing.T) {
+	sub := (WordListGenerator{}).Generate()
+	parts := strings.Split(sub, "-")
+	if len(parts) != 3 {
+		t.Errorf("expected 3 hyphen-separated words, got %d: %q", len(parts), sub)
+	}
+}
+
+func TestWordListGeneratorProducesValidSubdomain(t *testing.T) {
+	sub := (WordListGenerator{}).Generate()
+	ok, reason := ValidateSubdomain(sub)
+	if !ok {
+		t.Errorf("WordListGenerator produced invalid subdomain %q: %s", sub, reason)
+	}
+}
+
+func TestWordListGeneratorUsesOnlyListedWords(t *testing.T) {
+	sub := (WordListGenerator{}).Generate()
+	parts := strings.Split(sub, "-")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d: %q", len(parts), sub)
+	}
+	for _, adj := range parts[:2] {
+		if !contains(adjectiveWords, adj) {
+			t.Errorf("word %q is not in the adjective list", adj)
+		}
+	}
+	if !contains(nounWords, parts[2]) {
+		t.Errorf("word %q is not in the noun list", parts[2])
+	}
+}
+
+func TestFilterWordListDropsInvalidEntries(t *testing.T) {
+	filtered := filterWordList("brave\n\nUPPER\nwith-hyphen\nwith space\nvalid123\n")
+	want := []string{"brave", "valid123"}
+	if len(filtered) != len(want) {
+		t.Fatalf("expected %v, got %v", want, filtered)
+	}
+	for i, w := range want {
+		if filtered[i] != w {
+			t.Errorf("expected %v, got %v", want, filtered)
+			break
+		}
+	}
+}
+
+func contains(words []string, target string) bool {
+	for _, w := range words {
+		if w == target {
+			return true
+		}
+	}
+	return false
+}
@@ -12,20 +12,54 @@ import (
 
 const sessionFileName = "session.json"
 
+// currentSchemaVersion is bumped whenever the on-disk Session shape changes
+// in a way that makes older files unsafe to resume from (e.g. fields that
+// didn't exist yet, like ReconnectToken). Load rejects anything older.
+const currentSchemaVersion = 2
+
 // configDirOverride is set during tests to avoid polluting the real config.
 var configDirOverride string
 
 // TunnelEntry records one tunnel's identity so it can be resumed.
+// ReconnectToken is the server-issued token from the last AuthAckMessage;
+// it's presented on resume instead of the bare subdomain so the server can
+// validate that this client actually owns the session. Routes is set
+// instead of (not alongside) Port when the tunnel fans out to multiple
+// local backends by path prefix; see tunnel.ClientOptions.Routes.
+// CustomDomain and TLSMode mirror tunnel.ClientOptions so a resumed tunnel
+// keeps driving (or not driving) ACME the same way it did before exit.
+// TargetHost, TargetScheme, and TargetInsecure mirror tunnel.ClientOptions.Target;
+// TargetHost is empty for the common bare-port case, where the tunnel just
+// dials localhost:Port as before.
 type TunnelEntry struct {
-	Subdomain string `json:"subdomain"`
-	Port      int    `json:"port"`
-	Domain    string `json:"domain"`
+	Subdomain      string       `json:"subdomain"`
+	Port           int          `json:"port"`
+	Domain         string       `json:"domain"`
+	ReconnectToken string       `json:"reconnectToken,omitempty"`
+	Routes         []RouteEntry `json:"routes,omitempty"`
+	CustomDomain   string       `json:"customDomain,omitempty"`
+	TLSMode        string       `json:"tlsMode,omitempty"`
+	AcmeEmail      string       `json:"acmeEmail,omitempty"`
+	TargetHost     string       `json:"targetHost,omitempty"`
+	TargetScheme   string       `json:"targetScheme,omitempty"`
+	TargetInsecure bool         `json:"targetInsecure,omitempty"`
+}
+
+// RouteEntry is the on-disk form of a tunnel.Route, duplicated here rather
+// than imported so the session package (pure persistence) doesn't need to
+// depend on the tunnel package (runtime client behavior).
+type RouteEntry struct {
+	PathPrefix  string `json:"pathPrefix"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	StripPrefix bool   `json:"stripPrefix,omitempty"`
 }
 
 // Session is the on-disk representation of a resumable tunnel session.
 type Session struct {
-	Tunnels   []TunnelEntry `json:"tunnels"`
-	CreatedAt time.Time     `json:"createdAt"`
+	SchemaVersion int           `json:"schemaVersion"`
+	Tunnels       []TunnelEntry `json:"tunnels"`
+	CreatedAt     time.Time     `json:"createdAt"`
 }
 
 // configDir returns the xpose config directory, creating it if necessary when create is true.
@@ -63,11 +97,12 @@ func Save(s *Session) error {
 	if err != nil {
 		return err
 	}
+	s.SchemaVersion = currentSchemaVersion
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0o644)
+	return os.WriteFile(path, data, 0o600)
 }
 
 // Load reads the session from disk. Returns nil (with no error) if the file
@@ -91,6 +126,10 @@ func Load() (*Session, error) {
 		return nil, nil // treat corrupt file as missing
 	}
 
+	if s.SchemaVersion != currentSchemaVersion {
+		return nil, nil // old schema predates reconnect tokens; treat as expired
+	}
+
 	window := time.Duration(protocol.SessionResumeWindowSeconds) * time.Second
 	if time.Since(s.CreatedAt) > window {
 		return nil, nil // expired
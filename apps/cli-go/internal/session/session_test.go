@@ -43,6 +43,24 @@ func TestSaveAndLoad(t *testing.T) {
 	assert.Equal(t, 8080, loaded.Tunnels[1].Port)
 }
 
+func TestSaveAndLoad_PersistsReconnectToken(t *testing.T) {
+	setupTestDir(t)
+
+	s := &Session{
+		Tunnels: []TunnelEntry{
+			{Subdomain: "abc123", Port: 3000, Domain: "xpose.dev", ReconnectToken: "tok-1"},
+		},
+		CreatedAt: time.Now(),
+	}
+
+	require.NoError(t, Save(s))
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "tok-1", loaded.Tunnels[0].ReconnectToken)
+}
+
 func TestLoad_MissingFile(t *testing.T) {
 	setupTestDir(t)
 
@@ -96,6 +114,25 @@ func TestClear(t *testing.T) {
 	assert.Nil(t, loaded, "after Clear, Load should return nil")
 }
 
+func TestLoad_OldSchemaVersionTreatedAsExpired(t *testing.T) {
+	setupTestDir(t)
+
+	old := struct {
+		Tunnels   []TunnelEntry `json:"tunnels"`
+		CreatedAt time.Time     `json:"createdAt"`
+	}{
+		Tunnels:   []TunnelEntry{{Subdomain: "abc123", Port: 3000, Domain: "xpose.dev"}},
+		CreatedAt: time.Now(),
+	}
+	data, err := json.MarshalIndent(old, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(configDirOverride, sessionFileName), data, 0o644))
+
+	loaded, err := Load()
+	assert.NoError(t, err)
+	assert.Nil(t, loaded, "session file without a schema version predates reconnect tokens and must not be resumed")
+}
+
 func TestClear_NoFile(t *testing.T) {
 	setupTestDir(t)
 
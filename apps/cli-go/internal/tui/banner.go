@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"charm.land/lipgloss/v2"
+
+	"github.com/nkootstra/xpose/internal/tunnel"
 )
 
 // FormatTTL formats a TTL in seconds as "Xh Ym Zs".
@@ -19,13 +21,16 @@ func FormatTTL(seconds int) string {
 }
 
 // RenderTunnelCard produces a compact tunnel info card for the left panel.
-// Each connected tunnel looks like:
+// A single-target tunnel looks like:
 //
 //	✓ Connected to Cloudflare's edge network
 //	→ https://abc123.xpose.dev
 //	  Forwarding to http://localhost:3000
 //	  TTL: 3h 57m 12s
-func RenderTunnelCard(url string, port int, ttlRemaining int, status string, lastError string, spinnerView string) string {
+//
+// A tunnel with path-prefix routes (len(routes) > 1) lists one line per
+// route instead of the single "Forwarding to" line.
+func RenderTunnelCard(url string, target tunnel.Target, ttlRemaining int, status string, lastError string, spinnerView string, routes []tunnel.Route) string {
 	var b strings.Builder
 
 	switch status {
@@ -39,28 +44,42 @@ func RenderTunnelCard(url string, port int, ttlRemaining int, status string, las
 		linkedURL := urlStyle.Render(Hyperlink(url, url))
 		b.WriteString(fmt.Sprintf(" %s %s\n", arrow, linkedURL))
 
-		b.WriteString(fmt.Sprintf("   Forwarding to %s\n",
-			dimStyle.Render(fmt.Sprintf("http://localhost:%d", port)),
-		))
+		if len(routes) > 1 {
+			for _, r := range routes {
+				b.WriteString(fmt.Sprintf("   %s\n", dimStyle.Render(routeLine(r))))
+			}
+		} else {
+			b.WriteString(fmt.Sprintf("   Forwarding to %s\n",
+				dimStyle.Render(targetURL(target)),
+			))
+		}
 		b.WriteString(fmt.Sprintf("   TTL: %s\n", ttlStyle.Render(FormatTTL(ttlRemaining))))
 
 	case "connecting":
 		b.WriteString(fmt.Sprintf(" %s %s\n", spinnerView, statusStyles["connecting"].Render("Connecting...")))
-		b.WriteString(fmt.Sprintf("   Port %d\n", port))
+		b.WriteString(targetLine(target, routes))
 
 	case "reconnecting":
 		b.WriteString(fmt.Sprintf(" %s %s\n", spinnerView, statusStyles["reconnecting"].Render("Reconnecting...")))
-		b.WriteString(fmt.Sprintf("   Port %d\n", port))
+		b.WriteString(targetLine(target, routes))
+
+	case "resuming":
+		b.WriteString(fmt.Sprintf(" %s %s\n", spinnerView, statusStyles["resuming"].Render("Resuming...")))
+		b.WriteString(targetLine(target, routes))
+
+	case "reauthorizing":
+		b.WriteString(fmt.Sprintf(" %s %s\n", spinnerView, statusStyles["reauthorizing"].Render("Re-authorizing...")))
+		b.WriteString(targetLine(target, routes))
 
 	case "disconnected":
 		cross := lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("✗")
 		b.WriteString(fmt.Sprintf(" %s %s\n", cross, statusStyles["disconnected"].Render("Disconnected")))
-		b.WriteString(fmt.Sprintf("   Port %d\n", port))
+		b.WriteString(targetLine(target, routes))
 
 	case "expired":
 		cross := lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("✗")
 		b.WriteString(fmt.Sprintf(" %s %s\n", cross, statusStyles["expired"].Render("Tunnel expired")))
-		b.WriteString(fmt.Sprintf("   Port %d\n", port))
+		b.WriteString(targetLine(target, routes))
 	}
 
 	if lastError != "" && status != "connected" {
@@ -73,6 +92,40 @@ func RenderTunnelCard(url string, port int, ttlRemaining int, status string, las
 	return b.String()
 }
 
+// targetLine renders the line under a non-connected status line: one target
+// URL line for a single-target tunnel, or one line per route when the
+// tunnel fans out to several local backends by path prefix.
+func targetLine(target tunnel.Target, routes []tunnel.Route) string {
+	if len(routes) <= 1 {
+		return fmt.Sprintf("   %s\n", targetURL(target))
+	}
+	var b strings.Builder
+	for _, r := range routes {
+		b.WriteString(fmt.Sprintf("   %s\n", routeLine(r)))
+	}
+	return b.String()
+}
+
+// routeLine formats a single Route as "<prefix> -> <host>:<port>", with the
+// empty (default/fallback) prefix shown as "/".
+func routeLine(r tunnel.Route) string {
+	prefix := r.PathPrefix
+	if prefix == "" {
+		prefix = "/"
+	}
+	return fmt.Sprintf("%s -> %s:%d", prefix, r.Host, r.Port)
+}
+
+// targetURL formats a Target as a scheme://host:port string, defaulting an
+// unset Scheme to "http" the same way ProxyRequest does.
+func targetURL(t tunnel.Target) string {
+	scheme := t.Scheme
+	if scheme != "https" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, t.Host, t.Port)
+}
+
 // RenderCompactView produces the narrow-terminal view for all tunnels.
 // Matches the minimal mockup style with no traffic panel.
 func RenderCompactView(tunnels []tunnelViewData, spinnerView string) string {
@@ -82,7 +135,7 @@ func RenderCompactView(tunnels []tunnelViewData, spinnerView string) string {
 		if i > 0 {
 			b.WriteString("\n")
 		}
-		b.WriteString(RenderTunnelCard(t.url, t.port, t.ttlRemaining, t.status, t.lastError, spinnerView))
+		b.WriteString(RenderTunnelCard(t.url, t.target, t.ttlRemaining, t.status, t.lastError, spinnerView, t.routes))
 	}
 
 	return b.String()
@@ -91,10 +144,12 @@ func RenderCompactView(tunnels []tunnelViewData, spinnerView string) string {
 // tunnelViewData is passed from the model to the rendering functions.
 type tunnelViewData struct {
 	port         int
+	target       tunnel.Target
 	status       string
 	url          string
 	ttlRemaining int
 	lastError    string
+	routes       []tunnel.Route
 }
 
 // RenderBanner produces the legacy connection banner (kept for test compatibility).
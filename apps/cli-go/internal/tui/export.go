@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/nkootstra/xpose/internal/har"
+	"github.com/nkootstra/xpose/internal/version"
+)
+
+// ExportHAR serializes the in-memory traffic buffer (see maxTrafficEntries)
+// as a HAR 1.2 document to w. TCP-stream entries carry no HTTP semantics and
+// are skipped, same as har.Recorder's capture path.
+func (m Model) ExportHAR(w io.Writer) error {
+	entries := make([]har.Entry, 0, len(m.traffic))
+	for _, rec := range m.traffic {
+		if rec.kind != trafficHTTP || rec.http == nil {
+			continue
+		}
+		t := rec.http
+
+		var host string
+		if rec.tunnelIndex < len(m.tunnels) {
+			host = fmt.Sprintf("localhost:%d", m.tunnels[rec.tunnelIndex].port)
+		}
+
+		entries = append(entries, har.NewEntry(har.EntryParams{
+			StartedAt:       t.Timestamp.Add(-t.Duration),
+			Duration:        t.Duration,
+			Method:          t.Method,
+			Host:            host,
+			Path:            t.Path,
+			RequestHeaders:  t.RequestHeaders,
+			RequestBody:     t.RequestBody,
+			Status:          t.Status,
+			ResponseHeaders: t.ResponseHeaders,
+			ResponseBody:    t.ResponseBody,
+			BodyTruncated:   t.BodyTruncated,
+		}))
+	}
+
+	log := har.Log{
+		Log: har.LogBody{
+			Version: "1.2",
+			Creator: har.Creator{Name: "xpose", Version: version.Version},
+			Entries: entries,
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// exportHarMsg reports the outcome of the "e" keybinding's attempt to dump
+// the current session to a HAR file.
+type exportHarMsg struct {
+	path string
+	err  error
+}
+
+// exportSession returns a command that writes m's traffic buffer to an HAR
+// file named xpose-YYYYMMDD.har in the current directory.
+func exportSession(m Model) tea.Cmd {
+	return func() tea.Msg {
+		path := fmt.Sprintf("xpose-%s.har", time.Now().Format("20060102"))
+
+		f, err := os.Create(path)
+		if err != nil {
+			return exportHarMsg{err: err}
+		}
+		defer f.Close()
+
+		if err := m.ExportHAR(f); err != nil {
+			return exportHarMsg{err: err}
+		}
+		return exportHarMsg{path: path}
+	}
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nkootstra/xpose/internal/har"
+	"github.com/nkootstra/xpose/internal/tunnel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_ExportHAR_WritesHttpEntriesOnly(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+
+	m.traffic = []trafficRecord{
+		{
+			kind: trafficHTTP,
+			http: &tunnel.TrafficEntry{
+				Method:          "GET",
+				Path:            "/widgets",
+				Status:          200,
+				Duration:        5 * time.Millisecond,
+				Timestamp:       time.Now(),
+				RequestHeaders:  map[string]string{"Accept": "application/json"},
+				ResponseHeaders: map[string]string{"Content-Type": "application/json"},
+				ResponseBody:    []byte(`{"ok":true}`),
+			},
+			tunnelIndex: 0,
+		},
+		{kind: trafficTCP, line: "tcp stream"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, m.ExportHAR(&buf))
+
+	var log har.Log
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	assert.Equal(t, "1.2", log.Log.Version)
+	assert.Equal(t, "xpose", log.Log.Creator.Name)
+	require.Len(t, log.Log.Entries, 1)
+
+	entry := log.Log.Entries[0]
+	assert.Equal(t, "GET", entry.Request.Method)
+	assert.Equal(t, "http://localhost:3000/widgets", entry.Request.URL)
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, `{"ok":true}`, entry.Response.Content.Text)
+}
+
+func TestModel_ExportHAR_EmptyBufferProducesZeroEntries(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+
+	var buf bytes.Buffer
+	require.NoError(t, m.ExportHAR(&buf))
+
+	var log har.Log
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Empty(t, log.Log.Entries)
+}
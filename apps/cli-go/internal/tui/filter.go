@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/nkootstra/xpose/internal/tunnel"
+)
+
+// filterPredicate reports whether a single HTTP traffic entry matches a
+// compiled filter query.
+type filterPredicate func(entry tunnel.TrafficEntry) bool
+
+// parseFilter compiles a filter query into a predicate tree. Supported terms:
+// bare text (path substring), `method:POST`, `status:5xx` (wildcard on the
+// first status digit), `status:>=400` (also `<=`, `>`, `<`, `=`), and
+// `path:~<regex>`. Terms separated by whitespace AND together; `|` separates
+// OR'd groups, which bind looser than AND (so `a b|c` means `(a AND b) OR c`).
+func parseFilter(query string) (filterPredicate, error) {
+	var orPreds []filterPredicate
+	for _, group := range strings.Split(query, "|") {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+
+		var andPreds []filterPredicate
+		for _, term := range strings.Fields(group) {
+			pred, err := parseFilterTerm(term)
+			if err != nil {
+				return nil, err
+			}
+			andPreds = append(andPreds, pred)
+		}
+		if len(andPreds) > 0 {
+			orPreds = append(orPreds, andAll(andPreds))
+		}
+	}
+
+	if len(orPreds) == 0 {
+		return nil, fmt.Errorf("empty filter query")
+	}
+	return orAny(orPreds), nil
+}
+
+func andAll(preds []filterPredicate) filterPredicate {
+	return func(entry tunnel.TrafficEntry) bool {
+		for _, p := range preds {
+			if !p(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func orAny(preds []filterPredicate) filterPredicate {
+	return func(entry tunnel.TrafficEntry) bool {
+		for _, p := range preds {
+			if p(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func parseFilterTerm(term string) (filterPredicate, error) {
+	switch {
+	case strings.HasPrefix(term, "method:"):
+		method := strings.ToUpper(strings.TrimPrefix(term, "method:"))
+		return func(entry tunnel.TrafficEntry) bool {
+			return strings.ToUpper(entry.Method) == method
+		}, nil
+
+	case strings.HasPrefix(term, "status:"):
+		return parseStatusTerm(strings.TrimPrefix(term, "status:"))
+
+	case strings.HasPrefix(term, "path:~"):
+		pattern := strings.TrimPrefix(term, "path:~")
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path regex %q: %w", pattern, err)
+		}
+		return func(entry tunnel.TrafficEntry) bool {
+			return re.MatchString(entry.Path)
+		}, nil
+
+	default:
+		needle := strings.ToLower(term)
+		return func(entry tunnel.TrafficEntry) bool {
+			return strings.Contains(strings.ToLower(entry.Path), needle)
+		}, nil
+	}
+}
+
+var statusWildcardPattern = regexp.MustCompile(`^([2-5])xx$`)
+
+// parseStatusTerm handles the value after `status:`: an xx-wildcard
+// (`5xx`), a comparison (`>=400`), or an exact code (`404`).
+func parseStatusTerm(value string) (filterPredicate, error) {
+	if m := statusWildcardPattern.FindStringSubmatch(value); m != nil {
+		digit, _ := strconv.Atoi(m[1])
+		return func(entry tunnel.TrafficEntry) bool {
+			return entry.Status/100 == digit
+		}, nil
+	}
+
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		rest, ok := strings.CutPrefix(value, op)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status value %q: %w", rest, err)
+		}
+		return statusComparator(op, n), nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status filter %q", value)
+	}
+	return func(entry tunnel.TrafficEntry) bool {
+		return entry.Status == n
+	}, nil
+}
+
+func statusComparator(op string, n int) filterPredicate {
+	return func(entry tunnel.TrafficEntry) bool {
+		switch op {
+		case ">=":
+			return entry.Status >= n
+		case "<=":
+			return entry.Status <= n
+		case ">":
+			return entry.Status > n
+		case "<":
+			return entry.Status < n
+		default:
+			return entry.Status == n
+		}
+	}
+}
+
+// trafficVisible reports whether rec should be shown in the traffic log
+// under the active filter. TCP-stream entries carry no HTTP detail, so a
+// non-empty filter hides them rather than matching them unconditionally.
+func (m Model) trafficVisible(rec trafficRecord) bool {
+	if m.filterPred == nil {
+		return true
+	}
+	if rec.kind != trafficHTTP || rec.http == nil {
+		return false
+	}
+	return m.filterPred(*rec.http)
+}
+
+// prevVisibleIndex finds the nearest traffic entry before from that passes
+// the active filter.
+func (m Model) prevVisibleIndex(from int) (int, bool) {
+	for i := from - 1; i >= 0; i-- {
+		if m.trafficVisible(m.traffic[i]) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// nextVisibleIndex finds the nearest traffic entry after from that passes
+// the active filter.
+func (m Model) nextVisibleIndex(from int) (int, bool) {
+	for i := from + 1; i < len(m.traffic); i++ {
+		if m.trafficVisible(m.traffic[i]) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// updateFilterInput handles a keystroke while the filter bar is being
+// edited: text keys append to filterInput, backspace trims it, enter
+// compiles and commits it, and esc discards the edit.
+func (m *Model) updateFilterInput(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "esc":
+		m.filtering = false
+		m.filterInput = ""
+	case "enter":
+		m.filtering = false
+		m.applyFilter(m.filterInput)
+	case "backspace":
+		if len(m.filterInput) > 0 {
+			runes := []rune(m.filterInput)
+			m.filterInput = string(runes[:len(runes)-1])
+		}
+	default:
+		if text := msg.Key().Text; text != "" {
+			m.filterInput += text
+		}
+	}
+}
+
+// applyFilter compiles query and, on success, makes it the active filter.
+// An empty query clears the filter; a query that fails to parse leaves the
+// previous filter in place and records the error for the footer.
+func (m *Model) applyFilter(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		m.filterQuery = ""
+		m.filterPred = nil
+		m.filterErr = ""
+	} else if pred, err := parseFilter(query); err != nil {
+		m.filterErr = err.Error()
+	} else {
+		m.filterQuery = query
+		m.filterPred = pred
+		m.filterErr = ""
+	}
+
+	if m.ready {
+		m.updateViewportContent()
+	}
+}
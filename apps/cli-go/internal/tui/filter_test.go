@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/nkootstra/xpose/internal/tunnel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter_BareTextMatchesPathSubstring(t *testing.T) {
+	pred, err := parseFilter("widgets")
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Path: "/api/widgets"}))
+	assert.False(t, pred(tunnel.TrafficEntry{Path: "/api/gadgets"}))
+}
+
+func TestParseFilter_Method(t *testing.T) {
+	pred, err := parseFilter("method:POST")
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Method: "post"}))
+	assert.False(t, pred(tunnel.TrafficEntry{Method: "GET"}))
+}
+
+func TestParseFilter_StatusWildcard(t *testing.T) {
+	pred, err := parseFilter("status:5xx")
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Status: 503}))
+	assert.False(t, pred(tunnel.TrafficEntry{Status: 200}))
+}
+
+func TestParseFilter_StatusComparison(t *testing.T) {
+	pred, err := parseFilter("status:>=400")
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Status: 404}))
+	assert.False(t, pred(tunnel.TrafficEntry{Status: 399}))
+}
+
+func TestParseFilter_PathRegex(t *testing.T) {
+	pred, err := parseFilter(`path:~^/api/`)
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Path: "/api/widgets"}))
+	assert.False(t, pred(tunnel.TrafficEntry{Path: "/health"}))
+}
+
+func TestParseFilter_InvalidPathRegexErrors(t *testing.T) {
+	_, err := parseFilter(`path:~(`)
+	assert.Error(t, err)
+}
+
+func TestParseFilter_SpaceIsAnd(t *testing.T) {
+	pred, err := parseFilter("method:POST status:5xx")
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Method: "POST", Status: 500}))
+	assert.False(t, pred(tunnel.TrafficEntry{Method: "POST", Status: 200}))
+	assert.False(t, pred(tunnel.TrafficEntry{Method: "GET", Status: 500}))
+}
+
+func TestParseFilter_PipeIsOr(t *testing.T) {
+	pred, err := parseFilter("method:POST | status:5xx")
+	require.NoError(t, err)
+
+	assert.True(t, pred(tunnel.TrafficEntry{Method: "POST", Status: 200}))
+	assert.True(t, pred(tunnel.TrafficEntry{Method: "GET", Status: 500}))
+	assert.False(t, pred(tunnel.TrafficEntry{Method: "GET", Status: 200}))
+}
+
+func TestModel_ApplyFilter_HidesNonMatchingEntries(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+	m.traffic = []trafficRecord{
+		{kind: trafficHTTP, http: &tunnel.TrafficEntry{Method: "GET", Path: "/ok", Status: 200}},
+		{kind: trafficHTTP, http: &tunnel.TrafficEntry{Method: "POST", Path: "/fail", Status: 500}},
+		{kind: trafficTCP, line: "tcp stream"},
+	}
+
+	m.applyFilter("status:5xx")
+	assert.Equal(t, "status:5xx", m.filterQuery)
+	assert.False(t, m.trafficVisible(m.traffic[0]))
+	assert.True(t, m.trafficVisible(m.traffic[1]))
+	assert.False(t, m.trafficVisible(m.traffic[2]))
+}
+
+func TestModel_ApplyFilter_InvalidQueryKeepsPreviousFilter(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+	m.applyFilter("status:5xx")
+
+	m.applyFilter("path:~(")
+	assert.Equal(t, "status:5xx", m.filterQuery)
+	assert.NotEmpty(t, m.filterErr)
+}
+
+func TestModel_ApplyFilter_EmptyQueryClearsFilter(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+	m.applyFilter("status:5xx")
+	m.applyFilter("")
+
+	assert.Empty(t, m.filterQuery)
+	assert.Nil(t, m.filterPred)
+}
+
+func TestModel_SlashEntersFilterMode(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+	m.showSplit = true
+	m.focus = panelRight
+	m.traffic = []trafficRecord{{kind: trafficHTTP, http: &tunnel.TrafficEntry{Path: "/ok"}}}
+
+	msg := tea.KeyPressMsg{Code: '/', Text: "/"}
+	updated, _ := m.Update(msg)
+	um := updated.(Model)
+	assert.True(t, um.filtering)
+}
+
+func TestModel_EnterCommitsFilterQuery(t *testing.T) {
+	m := NewModel([]*tunnel.Client{nil}, []int{3000})
+	m.filtering = true
+	m.filterInput = "status:5xx"
+
+	msg := tea.KeyPressMsg{Code: tea.KeyEnter, Text: "enter"}
+	updated, _ := m.Update(msg)
+	um := updated.(Model)
+	assert.False(t, um.filtering)
+	assert.Equal(t, "status:5xx", um.filterQuery)
+}
@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/nkootstra/xpose/internal/protocol"
+	"github.com/nkootstra/xpose/internal/tunnel"
+)
+
+// trafficKind distinguishes the two kinds of rows that can appear in the
+// traffic log, since HTTP entries carry inspector detail that TCP streams
+// don't have.
+type trafficKind int
+
+const (
+	trafficHTTP trafficKind = iota
+	trafficTCP
+)
+
+// trafficRecord is one row of the traffic ring buffer. line is the
+// pre-rendered summary shown in the log view; http is non-nil for
+// trafficHTTP entries and feeds the inspector and replay-from-inspector.
+type trafficRecord struct {
+	kind        trafficKind
+	line        string
+	http        *tunnel.TrafficEntry
+	tunnelIndex int
+}
+
+// viewMode selects what the right panel renders: the scrolling traffic log,
+// or a full-detail inspector for one selected entry.
+type viewMode int
+
+const (
+	viewLog viewMode = iota
+	viewInspector
+)
+
+const bodyPreviewBytes = 4 * 1024
+
+// RenderInspector renders the full request/response detail for a single
+// captured HTTP exchange, wrapped to width.
+func RenderInspector(entry tunnel.TrafficEntry, width int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s  %s\n", StyledMethod(entry.Method), entry.Path)
+	fmt.Fprintf(&b, "%s %s  %s\n\n",
+		labelStyle.Render("Status"), StyledStatus(entry.Status),
+		dimStyle.Render(fmt.Sprintf("%dms", entry.Duration.Milliseconds())),
+	)
+
+	b.WriteString(panelTitleStyle.Render("Request"))
+	b.WriteString("\n")
+	b.WriteString(renderHeaders(entry.RequestHeaders))
+	if len(entry.RequestBody) > 0 {
+		b.WriteString("\n")
+		b.WriteString(renderBodyPreview(entry.RequestBody, entry.BodyTruncated))
+	}
+
+	b.WriteString("\n\n")
+	b.WriteString(panelTitleStyle.Render("Response"))
+	b.WriteString("\n")
+	b.WriteString(renderHeaders(entry.ResponseHeaders))
+	if len(entry.ResponseBody) > 0 {
+		b.WriteString("\n")
+		b.WriteString(renderBodyPreview(entry.ResponseBody, entry.BodyTruncated))
+	}
+
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
+
+// renderHeaders renders a header map sorted by name, one per line.
+func renderHeaders(headers map[string]string) string {
+	if len(headers) == 0 {
+		return dimStyle.Render("  (no headers)")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  %s: %s", dimStyle.Render(name), headers[name])
+	}
+	return b.String()
+}
+
+// prettyJSON returns body re-indented for readability if it parses as JSON,
+// or body unchanged otherwise. Captured bodies are rendered for a human to
+// read, not reparsed by anything downstream, so a failed parse just falls
+// back to the raw bytes rather than surfacing an error.
+func prettyJSON(body []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+// renderBodyPreview shows up to bodyPreviewBytes of a captured body, noting
+// when it was cut off by the capture cap or this preview's own cap. The
+// preview cap is applied before prettyJSON so View()'s per-frame redraws
+// re-indent at most bodyPreviewBytes rather than the full captured body.
+func renderBodyPreview(body []byte, captureTruncated bool) string {
+	preview := body
+	previewTruncated := captureTruncated
+	if len(preview) > bodyPreviewBytes {
+		preview = preview[:bodyPreviewBytes]
+		previewTruncated = true
+	}
+	preview = prettyJSON(preview)
+
+	out := string(preview)
+	if previewTruncated {
+		out += dimStyle.Render("\n  (truncated)")
+	}
+	return out
+}
+
+// replayResultMsg carries the outcome of re-issuing a captured request
+// through ReplayRequest.
+type replayResultMsg struct {
+	tunnelIndex int
+	entry       tunnel.TrafficEntry
+	err         error
+}
+
+// replayRequest returns a command that re-issues reqEntry against the local
+// target of clients[tunnelIndex] via tunnel.ProxyRequest, outside the normal
+// tunnel flow, so the TUI can offer a "replay" action from the inspector.
+func replayRequest(client *tunnel.Client, tunnelIndex int, reqEntry tunnel.TrafficEntry, maxBody int) tea.Cmd {
+	return func() tea.Msg {
+		target := client.LocalTarget(reqEntry.Path)
+
+		var body io.Reader
+		if len(reqEntry.RequestBody) > 0 {
+			body = bytes.NewReader(reqEntry.RequestBody)
+		}
+
+		msg := &protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      reqEntry.ID,
+			Method:  reqEntry.Method,
+			Path:    reqEntry.Path,
+			Headers: reqEntry.RequestHeaders,
+			HasBody: body != nil,
+		}
+
+		startTime := time.Now()
+		resp, err := tunnel.ProxyRequest(context.Background(), target.Host, target.Port, msg, body, maxBody, false, nil, target.Scheme, target.InsecureSkipVerify)
+		if err != nil {
+			return replayResultMsg{tunnelIndex: tunnelIndex, err: err}
+		}
+		defer resp.Body.Close()
+
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, int64(maxBody)))
+
+		return replayResultMsg{
+			tunnelIndex: tunnelIndex,
+			entry: tunnel.TrafficEntry{
+				ID:              reqEntry.ID,
+				Method:          reqEntry.Method,
+				Path:            reqEntry.Path,
+				Status:          resp.Status,
+				Duration:        time.Since(startTime),
+				Timestamp:       time.Now(),
+				RequestHeaders:  reqEntry.RequestHeaders,
+				RequestBody:     reqEntry.RequestBody,
+				ResponseHeaders: resp.Headers,
+				ResponseBody:    respBody,
+			},
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nkootstra/xpose/internal/tunnel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderInspector_ShowsRequestAndResponse(t *testing.T) {
+	entry := tunnel.TrafficEntry{
+		Method:          "POST",
+		Path:            "/api/widgets",
+		Status:          201,
+		Duration:        12 * time.Millisecond,
+		RequestHeaders:  map[string]string{"Content-Type": "application/json"},
+		RequestBody:     []byte(`{"name":"widget"}`),
+		ResponseHeaders: map[string]string{"Content-Type": "application/json"},
+		ResponseBody:    []byte(`{"id":1}`),
+	}
+
+	out := RenderInspector(entry, 80)
+	assert.Contains(t, out, "POST")
+	assert.Contains(t, out, "/api/widgets")
+	assert.Contains(t, out, "Content-Type")
+	assert.Contains(t, out, "\"name\": \"widget\"")
+	assert.Contains(t, out, "\"id\": 1")
+}
+
+func TestRenderInspector_PrettyPrintsJSONBody(t *testing.T) {
+	entry := tunnel.TrafficEntry{
+		Method:      "POST",
+		Path:        "/api/widgets",
+		RequestBody: []byte(`{"name":"widget","tags":["a","b"]}`),
+	}
+
+	out := RenderInspector(entry, 80)
+	assert.Contains(t, out, "\"name\": \"widget\",")
+	assert.Contains(t, out, "\"tags\": [")
+	assert.Contains(t, out, "\"a\",")
+	assert.Contains(t, out, "\"b\"")
+}
+
+func TestRenderInspector_NonJSONBodyPassesThrough(t *testing.T) {
+	entry := tunnel.TrafficEntry{
+		Method:      "POST",
+		Path:        "/upload",
+		RequestBody: []byte("plain text, not json"),
+	}
+
+	out := RenderInspector(entry, 80)
+	assert.Contains(t, out, "plain text, not json")
+}
+
+func TestRenderInspector_NoHeadersFallback(t *testing.T) {
+	entry := tunnel.TrafficEntry{Method: "GET", Path: "/"}
+	out := RenderInspector(entry, 80)
+	assert.Contains(t, out, "(no headers)")
+}
+
+func TestRenderInspector_TruncatesLongBody(t *testing.T) {
+	entry := tunnel.TrafficEntry{
+		Method:      "POST",
+		Path:        "/upload",
+		RequestBody: []byte(strings.Repeat("a", bodyPreviewBytes+100)),
+	}
+	out := RenderInspector(entry, 80)
+	assert.Contains(t, out, "(truncated)")
+}
+
+func TestReplayRequest_ReissuesAgainstLocalTarget(t *testing.T) {
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("replayed"))
+	}))
+	defer server.Close()
+
+	host, port := parseHostPort(server.URL)
+	client := tunnel.NewClient(tunnel.ClientOptions{Subdomain: "x", Host: host, Port: port})
+
+	cmd := replayRequest(client, 0, tunnel.TrafficEntry{
+		ID:          "req-1",
+		Method:      "POST",
+		Path:        "/echo",
+		RequestBody: []byte("hello"),
+	}, 1024)
+
+	msg := cmd()
+	result, ok := msg.(replayResultMsg)
+	assert.True(t, ok)
+	assert.NoError(t, result.err)
+	assert.Equal(t, http.StatusTeapot, result.entry.Status)
+	assert.Equal(t, "replayed", string(result.entry.ResponseBody))
+	assert.Equal(t, "hello", gotBody)
+}
+
+// parseHostPort pulls the host and port out of an httptest.Server URL,
+// mirroring the tunnel package's own test helper of the same name.
+func parseHostPort(url string) (string, int) {
+	parts := strings.Split(url, ":")
+	port := 0
+	if _, err := fmt.Sscanf(parts[len(parts)-1], "%d", &port); err != nil {
+		return "", 0
+	}
+	host := strings.TrimPrefix(strings.Join(parts[:len(parts)-1], ":"), "http://")
+	return host, port
+}
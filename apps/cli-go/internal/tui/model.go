@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/spinner"
 	"charm.land/bubbles/v2/viewport"
@@ -27,6 +28,7 @@ const (
 // tunnelState tracks the state of a single tunnel connection.
 type tunnelState struct {
 	port         int
+	target       tunnel.Target
 	status       tunnel.TunnelStatus
 	url          string
 	ttl          int // initial TTL from auth
@@ -34,13 +36,14 @@ type tunnelState struct {
 	maxBody      int
 	sessionID    string
 	lastError    string
+	routes       []tunnel.Route
 }
 
 // Model is the root Bubble Tea model for the xpose TUI.
 type Model struct {
 	clients   []*tunnel.Client
 	tunnels   []tunnelState
-	traffic   []string
+	traffic   []trafficRecord
 	spinner   spinner.Model
 	trafficVP viewport.Model // right panel: scrollable traffic log
 	ready     bool
@@ -51,6 +54,20 @@ type Model struct {
 	// Split-pane state
 	focus     focusedPanel
 	showSplit bool
+
+	// Inspector state
+	mode         viewMode
+	selected     int  // index into traffic of the highlighted/inspected entry
+	followLatest bool // keep selection pinned to the newest entry until the user moves it
+
+	// Filter state
+	filtering   bool            // currently editing a filter query
+	filterInput string          // in-progress query text while filtering
+	filterQuery string          // last successfully committed query, shown in the footer
+	filterPred  filterPredicate // compiled from filterQuery; nil means show everything
+	filterErr   string          // parse error from the last commit attempt, if any
+
+	exportMsg string // result of the last "e" (export HAR) keypress, shown in the footer
 }
 
 // NewModel creates a new TUI model with the given tunnel clients and ports.
@@ -61,18 +78,27 @@ func NewModel(clients []*tunnel.Client, ports []int) Model {
 
 	tunnels := make([]tunnelState, len(clients))
 	for i, port := range ports {
+		var routes []tunnel.Route
+		target := tunnel.Target{Host: "localhost", Port: port}
+		if clients[i] != nil {
+			routes = clients[i].Routes()
+			target = clients[i].LocalTarget("")
+		}
 		tunnels[i] = tunnelState{
 			port:   port,
+			target: target,
 			status: tunnel.StatusConnecting,
+			routes: routes,
 		}
 	}
 
 	return Model{
-		clients: clients,
-		tunnels: tunnels,
-		traffic: make([]string, 0, maxTrafficEntries),
-		spinner: s,
-		focus:   panelRight, // default focus on traffic
+		clients:      clients,
+		tunnels:      tunnels,
+		traffic:      make([]trafficRecord, 0, maxTrafficEntries),
+		spinner:      s,
+		focus:        panelRight, // default focus on traffic
+		followLatest: true,
 	}
 }
 
@@ -82,10 +108,12 @@ func (m Model) tunnelViewDataSlice() []tunnelViewData {
 	for i, t := range m.tunnels {
 		data[i] = tunnelViewData{
 			port:         t.port,
+			target:       t.target,
 			status:       string(t.status),
 			url:          t.url,
 			ttlRemaining: t.ttlRemaining,
 			lastError:    t.lastError,
+			routes:       t.routes,
 		}
 	}
 	return data
@@ -99,9 +127,9 @@ func (m Model) renderLeftPanel() string {
 			b.WriteString("\n")
 		}
 		b.WriteString(RenderTunnelCard(
-			t.url, t.port, t.ttlRemaining,
+			t.url, t.target, t.ttlRemaining,
 			string(t.status), t.lastError,
-			m.spinner.View(),
+			m.spinner.View(), t.routes,
 		))
 	}
 	return b.String()
@@ -109,15 +137,40 @@ func (m Model) renderLeftPanel() string {
 
 // renderFooter builds the footer string.
 func (m Model) renderFooter() string {
+	if m.filtering {
+		return dimStyle.Render(fmt.Sprintf("  /%s", m.filterInput))
+	}
+
 	if m.showSplit {
-		hint := "  q quit | b open browser | tab switch panel"
+		if m.mode == viewInspector {
+			hint := "  q quit | esc/tab back | ↑↓ select"
+			if m.traffic[m.selected].kind == trafficHTTP {
+				hint += " | r replay"
+			}
+			return dimStyle.Render(hint)
+		}
+
+		hint := "  q quit | b open browser | e export HAR | tab switch panel | / filter"
 		if m.focus == panelRight && m.ready && len(m.traffic) > 0 {
 			pct := m.trafficVP.ScrollPercent()
-			hint += fmt.Sprintf(" | ↑↓ scroll | %3.0f%%", pct*100)
+			hint += fmt.Sprintf(" | ↑↓ select | enter inspect | %3.0f%%", pct*100)
+		}
+		switch {
+		case m.filterQuery != "":
+			hint += fmt.Sprintf(" | filter: %s", m.filterQuery)
+		case m.filterErr != "":
+			hint += fmt.Sprintf(" | invalid filter: %s", m.filterErr)
+		}
+		if m.exportMsg != "" {
+			hint += fmt.Sprintf(" | %s", m.exportMsg)
 		}
 		return dimStyle.Render(hint)
 	}
-	return dimStyle.Render("  q quit | b open browser")
+	footer := "  q quit | b open browser | e export HAR"
+	if m.exportMsg != "" {
+		footer += fmt.Sprintf(" | %s", m.exportMsg)
+	}
+	return dimStyle.Render(footer)
 }
 
 // syncLayout recalculates viewport dimensions based on terminal size.
@@ -184,6 +237,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.filtering {
+			m.updateFilterInput(msg)
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.quitting = true
@@ -199,18 +257,80 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case "tab":
-			if m.showSplit {
+			if m.mode == viewInspector {
+				m.mode = viewLog
+			} else if m.showSplit {
 				if m.focus == panelLeft {
 					m.focus = panelRight
 				} else {
 					m.focus = panelLeft
 				}
 			}
+		case "esc":
+			if m.mode == viewInspector {
+				m.mode = viewLog
+			}
+		case "enter":
+			if m.showSplit && m.focus == panelRight && m.mode == viewLog && len(m.traffic) > 0 {
+				m.mode = viewInspector
+			}
+		case "up", "k":
+			if m.showSplit && m.focus == panelRight && len(m.traffic) > 0 {
+				if idx, ok := m.prevVisibleIndex(m.selected); ok {
+					m.selected = idx
+				}
+				m.followLatest = false
+			}
+		case "down", "j":
+			if m.showSplit && m.focus == panelRight && len(m.traffic) > 0 {
+				if idx, ok := m.nextVisibleIndex(m.selected); ok {
+					m.selected = idx
+				}
+				m.followLatest = m.selected == len(m.traffic)-1
+			}
+		case "r":
+			if m.mode == viewInspector && len(m.traffic) > 0 {
+				rec := m.traffic[m.selected]
+				if rec.kind == trafficHTTP && rec.http != nil {
+					idx := rec.tunnelIndex
+					maxBody := m.tunnels[idx].maxBody
+					cmds = append(cmds, replayRequest(m.clients[idx], idx, *rec.http, maxBody))
+				}
+			}
+		case "/":
+			if m.showSplit && m.focus == panelRight && m.mode == viewLog {
+				m.filtering = true
+				m.filterInput = m.filterQuery
+			}
+		case "e":
+			cmds = append(cmds, exportSession(m))
 		}
 
 	case openBrowserMsg:
 		// Nothing to do — could show an error in a future iteration.
 
+	case exportHarMsg:
+		if msg.err != nil {
+			m.exportMsg = fmt.Sprintf("export failed: %s", msg.err.Error())
+		} else {
+			m.exportMsg = fmt.Sprintf("exported to %s", msg.path)
+		}
+
+	case replayResultMsg:
+		if msg.err != nil {
+			m.tunnels[msg.tunnelIndex].lastError = msg.err.Error()
+			return m, nil
+		}
+		entry := msg.entry
+		line := RenderTrafficLine(entry.Method, entry.Path, entry.Status, entry.Duration, entry.Timestamp)
+		m.appendTraffic(trafficRecord{
+			kind:        trafficHTTP,
+			line:        line,
+			http:        &entry,
+			tunnelIndex: msg.tunnelIndex,
+		})
+		m.selected = len(m.traffic) - 1
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -244,7 +364,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.tunnels[idx].ttlRemaining = ev.Authenticated.TTL
 				m.tunnels[idx].maxBody = ev.Authenticated.MaxBodySizeBytes
 				m.tunnels[idx].sessionID = ev.Authenticated.SessionID
-				m.tunnels[idx].status = tunnel.StatusConnected
+				// Status is set by the "status" event client.go always emits
+				// right after "authenticated" (Connected, or Resuming while
+				// it waits on a ResumeAckMessage) — not here, so a resume in
+				// progress isn't briefly clobbered back to Connected.
 			}
 
 		case "traffic":
@@ -260,17 +383,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					ev.Traffic.Duration,
 					ev.Traffic.Timestamp,
 				)
-				m.traffic = append(m.traffic, line)
-				if len(m.traffic) > maxTrafficEntries {
-					m.traffic = m.traffic[len(m.traffic)-maxTrafficEntries:]
-				}
-				if m.ready {
-					m.updateViewportContent()
-					m.trafficVP.GotoBottom()
-				}
+				m.appendTraffic(trafficRecord{
+					kind:        trafficHTTP,
+					line:        line,
+					http:        ev.Traffic,
+					tunnelIndex: idx,
+				})
 			}
 
-		case "error":
+		case "tcp-stream":
+			if ev.Tcp != nil {
+				line := RenderTcpStreamLine(
+					ev.Tcp.RemoteAddr,
+					ev.Tcp.BytesIn,
+					ev.Tcp.BytesOut,
+					ev.Tcp.Closed,
+					time.Now(),
+				)
+				m.appendTraffic(trafficRecord{
+					kind:        trafficTCP,
+					line:        line,
+					tunnelIndex: idx,
+				})
+			}
+
+		case "error", "auth_error":
 			if ev.Error != nil {
 				m.tunnels[idx].lastError = ev.Error.Error()
 			}
@@ -303,16 +440,55 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-// updateViewportContent sets the viewport content from the traffic log.
+// appendTraffic pushes rec onto the ring buffer, evicting the oldest entry
+// past maxTrafficEntries and keeping the selection pinned to the newest
+// entry while the user hasn't manually moved it away.
+func (m *Model) appendTraffic(rec trafficRecord) {
+	m.traffic = append(m.traffic, rec)
+	if len(m.traffic) > maxTrafficEntries {
+		overflow := len(m.traffic) - maxTrafficEntries
+		m.traffic = m.traffic[overflow:]
+		m.selected -= overflow
+		if m.selected < 0 {
+			m.selected = 0
+		}
+	}
+	if m.followLatest {
+		m.selected = len(m.traffic) - 1
+	}
+	if m.ready {
+		m.updateViewportContent()
+		m.trafficVP.GotoBottom()
+	}
+}
+
+// updateViewportContent sets the viewport content from the traffic log,
+// highlighting the selected entry while the traffic panel has focus.
 func (m *Model) updateViewportContent() {
 	if !m.ready {
 		return
 	}
-	content := strings.Join(m.traffic, "\n")
 	if len(m.traffic) == 0 {
-		content = dimStyle.Render(" Waiting for requests...")
+		m.trafficVP.SetContent(dimStyle.Render(" Waiting for requests..."))
+		return
+	}
+
+	var lines []string
+	for i, rec := range m.traffic {
+		if !m.trafficVisible(rec) {
+			continue
+		}
+		if i == m.selected && m.focus == panelRight {
+			lines = append(lines, selectedLineStyle.Render(rec.line))
+		} else {
+			lines = append(lines, rec.line)
+		}
 	}
-	m.trafficVP.SetContent(content)
+	if len(lines) == 0 {
+		m.trafficVP.SetContent(dimStyle.Render(" No entries match the filter"))
+		return
+	}
+	m.trafficVP.SetContent(strings.Join(lines, "\n"))
 }
 
 // View renders the TUI display.
@@ -364,9 +540,19 @@ func (m Model) renderSplitView() string {
 
 	// Build right panel content
 	var rightContent string
-	if m.ready {
+	rightTitleText := " Traffic "
+	switch {
+	case m.mode == viewInspector && len(m.traffic) > 0:
+		rec := m.traffic[m.selected]
+		if rec.http != nil {
+			rightContent = RenderInspector(*rec.http, rightWidth-borderH)
+		} else {
+			rightContent = rec.line
+		}
+		rightTitleText = " Inspector "
+	case m.ready:
 		rightContent = m.trafficVP.View()
-	} else {
+	default:
 		rightContent = dimStyle.Render(" Initializing...")
 	}
 
@@ -374,14 +560,14 @@ func (m Model) renderSplitView() string {
 	leftStyle := blurredBorderStyle()
 	rightStyle := blurredBorderStyle()
 	leftTitle := dimStyle.Render(" Tunnels ")
-	rightTitle := dimStyle.Render(" Traffic ")
+	rightTitle := dimStyle.Render(rightTitleText)
 
 	if m.focus == panelLeft {
 		leftStyle = focusedBorderStyle()
 		leftTitle = panelTitleStyle.Render(" Tunnels ")
 	} else {
 		rightStyle = focusedBorderStyle()
-		rightTitle = panelTitleStyle.Render(" Traffic ")
+		rightTitle = panelTitleStyle.Render(rightTitleText)
 	}
 
 	// Apply dimensions to panel styles.
@@ -474,6 +660,10 @@ func (m Model) ViewString() string {
 			b.WriteString(fmt.Sprintf("\n  %s\n", StyledTunnelStatus("connecting")))
 		case tunnel.StatusReconnecting:
 			b.WriteString(fmt.Sprintf("\n  %s\n", StyledTunnelStatus("reconnecting")))
+		case tunnel.StatusResuming:
+			b.WriteString(fmt.Sprintf("\n  %s\n", StyledTunnelStatus("resuming")))
+		case tunnel.StatusReauthorizing:
+			b.WriteString(fmt.Sprintf("\n  %s\n", StyledTunnelStatus("reauthorizing")))
 		case tunnel.StatusDisconnected:
 			b.WriteString(fmt.Sprintf("\n  %s\n", StyledTunnelStatus("disconnected")))
 		case tunnel.StatusExpired:
@@ -481,8 +671,8 @@ func (m Model) ViewString() string {
 		}
 	}
 
-	for _, line := range m.traffic {
-		b.WriteString(line + "\n")
+	for _, rec := range m.traffic {
+		b.WriteString(rec.line + "\n")
 	}
 
 	return b.String()
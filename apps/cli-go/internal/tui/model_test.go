@@ -46,10 +46,19 @@ func TestModel_HandleAuthenticated(t *testing.T) {
 
 	newM, _ := m.Update(msg)
 	model := newM.(Model)
-	assert.Equal(t, tunnel.StatusConnected, model.tunnels[0].status)
+	// "authenticated" alone doesn't touch status; client.go always follows it
+	// with a "status" event, which is what actually drives this transition.
+	assert.Equal(t, tunnel.StatusConnecting, model.tunnels[0].status)
 	assert.Equal(t, "https://a.xpose.dev", model.tunnels[0].url)
 	assert.Equal(t, 3600, model.tunnels[0].ttl)
 	assert.Equal(t, 3600, model.tunnels[0].ttlRemaining)
+
+	newM, _ = model.Update(tunnelEventMsg{
+		tunnelIndex: 0,
+		event:       tunnel.TunnelEvent{Type: "status", Status: tunnel.StatusConnected},
+	})
+	model = newM.(Model)
+	assert.Equal(t, tunnel.StatusConnected, model.tunnels[0].status)
 }
 
 func TestModel_HandleTraffic(t *testing.T) {
@@ -76,7 +85,35 @@ func TestModel_HandleTraffic(t *testing.T) {
 	newM, _ := m.Update(msg)
 	model := newM.(Model)
 	assert.Len(t, model.traffic, 1)
-	assert.Contains(t, model.traffic[0], "GET")
+	assert.Contains(t, model.traffic[0].line, "GET")
+	assert.Equal(t, trafficHTTP, model.traffic[0].kind)
+	assert.Equal(t, "req-1", model.traffic[0].http.ID)
+}
+
+func TestModel_HandleTcpStream(t *testing.T) {
+	clients := []*tunnel.Client{
+		tunnel.NewClient(tunnel.ClientOptions{Subdomain: "a", Port: 3000, Mode: "tcp"}),
+	}
+	m := NewModel(clients, []int{3000})
+
+	msg := tunnelEventMsg{
+		tunnelIndex: 0,
+		event: tunnel.TunnelEvent{
+			Type: "tcp-stream",
+			Tcp: &tunnel.TcpStreamInfo{
+				StreamID:   "stream-1",
+				RemoteAddr: "203.0.113.5:51342",
+				BytesIn:    128,
+				BytesOut:   256,
+			},
+		},
+	}
+
+	newM, _ := m.Update(msg)
+	model := newM.(Model)
+	assert.Len(t, model.traffic, 1)
+	assert.Contains(t, model.traffic[0].line, "203.0.113.5:51342")
+	assert.Equal(t, trafficTCP, model.traffic[0].kind)
 }
 
 func TestModel_ViewConnected(t *testing.T) {
@@ -108,7 +145,11 @@ func TestModel_ViewWithTraffic(t *testing.T) {
 	m.tunnels[0].url = "https://a.xpose.dev"
 
 	// Add traffic
-	m.traffic = append(m.traffic, RenderTrafficLine("POST", "/submit", 201, 15*time.Millisecond, time.Now()))
+	m.traffic = append(m.traffic, trafficRecord{
+		kind: trafficHTTP,
+		line: RenderTrafficLine("POST", "/submit", 201, 15*time.Millisecond, time.Now()),
+		http: &tunnel.TrafficEntry{Method: "POST", Path: "/submit", Status: 201},
+	})
 
 	view := m.ViewString()
 	assert.Contains(t, view, "POST")
@@ -141,10 +182,7 @@ func TestModel_TrafficRingBuffer(t *testing.T) {
 	m := NewModel(clients, []int{3000})
 
 	for i := 0; i < 150; i++ {
-		m.traffic = append(m.traffic, "line")
-	}
-	if len(m.traffic) > maxTrafficEntries {
-		m.traffic = m.traffic[len(m.traffic)-maxTrafficEntries:]
+		m.appendTraffic(trafficRecord{kind: trafficTCP, line: "line"})
 	}
 
 	assert.Len(t, m.traffic, maxTrafficEntries)
@@ -274,19 +312,38 @@ func TestModel_SyncLayout_SplitMode(t *testing.T) {
 }
 
 func TestRenderTunnelCard_Connected(t *testing.T) {
-	card := RenderTunnelCard("https://test.xpose.dev", 3000, 3600, "connected", "", "")
+	target := tunnel.Target{Host: "localhost", Port: 3000}
+	card := RenderTunnelCard("https://test.xpose.dev", target, 3600, "connected", "", "", nil)
 	assert.Contains(t, card, "Connected")
 	assert.Contains(t, card, "https://test.xpose.dev")
-	assert.Contains(t, card, "localhost:3000")
+	assert.Contains(t, card, "http://localhost:3000")
 	assert.Contains(t, card, "1h 0m 0s")
 }
 
 func TestRenderTunnelCard_Connecting(t *testing.T) {
-	card := RenderTunnelCard("", 3000, 0, "connecting", "", "⣾")
+	target := tunnel.Target{Host: "localhost", Port: 3000}
+	card := RenderTunnelCard("", target, 0, "connecting", "", "⣾", nil)
 	assert.Contains(t, card, "Connecting")
 	assert.Contains(t, card, "3000")
 }
 
+func TestRenderTunnelCard_ConnectedWithTLSTarget(t *testing.T) {
+	target := tunnel.Target{Scheme: "https", Host: "10.2.3.5", Port: 3443, InsecureSkipVerify: true}
+	card := RenderTunnelCard("https://test.xpose.dev", target, 3600, "connected", "", "", nil)
+	assert.Contains(t, card, "https://10.2.3.5:3443")
+}
+
+func TestRenderTunnelCard_ConnectedWithRoutes(t *testing.T) {
+	routes := []tunnel.Route{
+		{PathPrefix: "/api", Host: "localhost", Port: 3001},
+		{PathPrefix: "", Host: "localhost", Port: 3000},
+	}
+	card := RenderTunnelCard("https://test.xpose.dev", tunnel.Target{}, 3600, "connected", "", "", routes)
+	assert.Contains(t, card, "Connected")
+	assert.Contains(t, card, "/api -> localhost:3001")
+	assert.Contains(t, card, "/ -> localhost:3000")
+}
+
 func TestRenderCompactView_MultipleTunnels(t *testing.T) {
 	data := []tunnelViewData{
 		{port: 3000, status: "connected", url: "https://a.xpose.dev", ttlRemaining: 3600},
@@ -323,3 +380,94 @@ func TestInjectBorderTitle(t *testing.T) {
 	assert.Contains(t, lines[0], "Title")
 	assert.True(t, strings.HasPrefix(lines[0], "╭"))
 }
+
+func TestModel_EnterOpensInspector(t *testing.T) {
+	clients := []*tunnel.Client{
+		tunnel.NewClient(tunnel.ClientOptions{Subdomain: "a", Port: 3000}),
+	}
+	m := NewModel(clients, []int{3000})
+	m.showSplit = true
+	m.focus = panelRight
+	m.appendTraffic(trafficRecord{
+		kind: trafficHTTP,
+		line: "GET /",
+		http: &tunnel.TrafficEntry{Method: "GET", Path: "/"},
+	})
+
+	msg := tea.KeyPressMsg{Code: tea.KeyEnter, Text: "enter"}
+	newM, _ := m.Update(msg)
+	model := newM.(Model)
+	assert.Equal(t, viewInspector, model.mode)
+}
+
+func TestModel_TabFromInspectorReturnsToLog(t *testing.T) {
+	clients := []*tunnel.Client{
+		tunnel.NewClient(tunnel.ClientOptions{Subdomain: "a", Port: 3000}),
+	}
+	m := NewModel(clients, []int{3000})
+	m.showSplit = true
+	m.mode = viewInspector
+
+	msg := tea.KeyPressMsg{Code: tea.KeyTab, Text: "tab"}
+	newM, _ := m.Update(msg)
+	model := newM.(Model)
+	assert.Equal(t, viewLog, model.mode)
+}
+
+func TestModel_UpDownMovesSelectionAndStopsFollowing(t *testing.T) {
+	clients := []*tunnel.Client{
+		tunnel.NewClient(tunnel.ClientOptions{Subdomain: "a", Port: 3000}),
+	}
+	m := NewModel(clients, []int{3000})
+	m.showSplit = true
+	m.focus = panelRight
+	for i := 0; i < 3; i++ {
+		m.appendTraffic(trafficRecord{kind: trafficTCP, line: "line"})
+	}
+	assert.Equal(t, 2, m.selected)
+
+	msg := tea.KeyPressMsg{Code: tea.KeyUp, Text: "up"}
+	newM, _ := m.Update(msg)
+	model := newM.(Model)
+	assert.Equal(t, 1, model.selected)
+	assert.False(t, model.followLatest)
+
+	model.appendTraffic(trafficRecord{kind: trafficTCP, line: "line"})
+	assert.Equal(t, 1, model.selected, "selection should stay put once the user has moved it")
+}
+
+func TestModel_ReplayKeyIgnoredOutsideInspector(t *testing.T) {
+	clients := []*tunnel.Client{
+		tunnel.NewClient(tunnel.ClientOptions{Subdomain: "a", Port: 3000}),
+	}
+	m := NewModel(clients, []int{3000})
+	m.showSplit = true
+	m.focus = panelRight
+	m.appendTraffic(trafficRecord{
+		kind: trafficHTTP,
+		line: "GET /",
+		http: &tunnel.TrafficEntry{Method: "GET", Path: "/"},
+	})
+
+	msg := tea.KeyPressMsg{Code: 'r', Text: "r"}
+	_, cmd := m.Update(msg)
+	assert.Nil(t, cmd)
+}
+
+func TestModel_ReplayResultAppendsToLog(t *testing.T) {
+	clients := []*tunnel.Client{
+		tunnel.NewClient(tunnel.ClientOptions{Subdomain: "a", Port: 3000}),
+	}
+	m := NewModel(clients, []int{3000})
+
+	msg := replayResultMsg{
+		tunnelIndex: 0,
+		entry: tunnel.TrafficEntry{
+			Method: "GET", Path: "/replayed", Status: 200,
+		},
+	}
+	newM, _ := m.Update(msg)
+	model := newM.(Model)
+	assert.Len(t, model.traffic, 1)
+	assert.Equal(t, "/replayed", model.traffic[0].http.Path)
+}
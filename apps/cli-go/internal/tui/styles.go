@@ -15,12 +15,18 @@ var (
 	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))            // red
 	dimStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))            // gray
 
+	// selectedLineStyle highlights the traffic-log row the inspector would
+	// open for, in reverse video.
+	selectedLineStyle = lipgloss.NewStyle().Reverse(true)
+
 	statusStyles = map[string]lipgloss.Style{
-		"connecting":   lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
-		"connected":    lipgloss.NewStyle().Foreground(lipgloss.Color("2")), // green
-		"reconnecting": lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
-		"disconnected": lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
-		"expired":      lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
+		"connecting":    lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
+		"connected":     lipgloss.NewStyle().Foreground(lipgloss.Color("2")), // green
+		"reconnecting":  lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
+		"resuming":      lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
+		"reauthorizing": lipgloss.NewStyle().Foreground(lipgloss.Color("3")), // yellow
+		"disconnected":  lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
+		"expired":       lipgloss.NewStyle().Foreground(lipgloss.Color("1")), // red
 	}
 
 	methodStyles = map[string]lipgloss.Style{
@@ -88,11 +94,13 @@ func StyledStatus(status int) string {
 // StyledTunnelStatus returns a styled status label.
 func StyledTunnelStatus(status string) string {
 	labels := map[string]string{
-		"connecting":   "Connecting...",
-		"connected":    "Connected",
-		"reconnecting": "Reconnecting...",
-		"disconnected": "Disconnected",
-		"expired":      "Tunnel expired",
+		"connecting":    "Connecting...",
+		"connected":     "Connected",
+		"reconnecting":  "Reconnecting...",
+		"resuming":      "Resuming...",
+		"reauthorizing": "Re-authorizing...",
+		"disconnected":  "Disconnected",
+		"expired":       "Tunnel expired",
 	}
 	label, ok := labels[status]
 	if !ok {
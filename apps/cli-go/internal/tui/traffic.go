@@ -23,3 +23,24 @@ func RenderTrafficLine(method, path string, status int, duration time.Duration,
 		dur,
 	)
 }
+
+// RenderTcpStreamLine produces a formatted traffic log line for a raw TCP
+// stream, showing connection lifecycle and bytes transferred instead of the
+// method/status used for HTTP traffic.
+func RenderTcpStreamLine(remoteAddr string, bytesIn, bytesOut int64, closed bool, ts time.Time) string {
+	timeStr := dimStyle.Render(ts.Format("15:04:05"))
+	label := "open"
+	if closed {
+		label = "closed"
+	}
+	status := dimStyle.Render(fmt.Sprintf("%-6s", label))
+	counts := dimStyle.Render(fmt.Sprintf("in %dB / out %dB", bytesIn, bytesOut))
+
+	return fmt.Sprintf("  %s  %-7s  %-22s  %s  %s",
+		timeStr,
+		"TCP",
+		remoteAddr,
+		status,
+		counts,
+	)
+}
@@ -40,6 +40,20 @@ func TestRenderTrafficLine_DifferentStatuses(t *testing.T) {
 	}
 }
 
+func TestRenderTcpStreamLine_ContainsAddrAndCounts(t *testing.T) {
+	ts := time.Date(2025, 1, 15, 14, 30, 45, 0, time.UTC)
+	line := RenderTcpStreamLine("203.0.113.5:51342", 128, 256, false, ts)
+	assert.Contains(t, line, "203.0.113.5:51342")
+	assert.Contains(t, line, "in 128B")
+	assert.Contains(t, line, "out 256B")
+	assert.Contains(t, line, "open")
+}
+
+func TestRenderTcpStreamLine_ShowsClosed(t *testing.T) {
+	line := RenderTcpStreamLine("203.0.113.5:51342", 0, 0, true, time.Now())
+	assert.Contains(t, line, "closed")
+}
+
 func TestFormatTTL(t *testing.T) {
 	tests := []struct {
 		seconds  int
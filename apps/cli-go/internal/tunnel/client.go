@@ -1,36 +1,82 @@
 package tunnel
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/coder/websocket"
 
+	"github.com/nkootstra/xpose/internal/acme"
+	"github.com/nkootstra/xpose/internal/har"
+	"github.com/nkootstra/xpose/internal/metrics"
 	"github.com/nkootstra/xpose/internal/protocol"
+	"github.com/nkootstra/xpose/internal/version"
 )
 
+// chunkBufPool pools the buffers used to pump response bodies into binary
+// frames, so high request volume doesn't churn a fresh 64KB slice per chunk.
+var chunkBufPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 64*1024)
+		return &buf
+	},
+}
+
+// defaultMinCompressSize is the minimum known body size worth gzip-compressing
+// over the wire when ClientOptions.MinCompressSize is left at 0; bodies
+// smaller than this rarely recoup gzip's framing overhead.
+const defaultMinCompressSize = 1024
+
+// minCompressSize returns opts.MinCompressSize, falling back to
+// defaultMinCompressSize when unset.
+func (c *Client) minCompressSize() int {
+	if c.opts.MinCompressSize > 0 {
+		return c.opts.MinCompressSize
+	}
+	return defaultMinCompressSize
+}
+
 // TunnelStatus represents the connection state of a tunnel.
 type TunnelStatus string
 
 const (
-	StatusConnecting   TunnelStatus = "connecting"
-	StatusConnected    TunnelStatus = "connected"
-	StatusReconnecting TunnelStatus = "reconnecting"
-	StatusDisconnected TunnelStatus = "disconnected"
-	StatusExpired      TunnelStatus = "expired"
+	StatusConnecting    TunnelStatus = "connecting"
+	StatusConnected     TunnelStatus = "connected"
+	StatusReconnecting  TunnelStatus = "reconnecting"
+	StatusResuming      TunnelStatus = "resuming"
+	StatusReauthorizing TunnelStatus = "reauthorizing"
+	StatusDisconnected  TunnelStatus = "disconnected"
+	StatusExpired       TunnelStatus = "expired"
 )
 
-// TrafficEntry records a single proxied HTTP request.
+// TrafficEntry records a single proxied HTTP request. RequestBody and
+// ResponseBody are preview-capped independent of the tunnel's own
+// max-body-size limit (see har.CaptureReader), so the TUI inspector can hold
+// a window of recent entries without its memory tracking MaxBodySizeBytes.
 type TrafficEntry struct {
-	ID        string
-	Method    string
-	Path      string
-	Status    int
-	Duration  time.Duration
-	Timestamp time.Time
+	ID              string
+	Method          string
+	Path            string
+	Status          int
+	Duration        time.Duration
+	Timestamp       time.Time
+	RequestHeaders  map[string]string
+	RequestBody     []byte
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	BodyTruncated   bool
+	Route           string // PathPrefix of the Routes entry that handled this request, empty if opts.Routes is unset
 }
 
 // AuthenticatedInfo is emitted after a successful auth-ack.
@@ -39,6 +85,42 @@ type AuthenticatedInfo struct {
 	TTL              int
 	SessionID        string
 	MaxBodySizeBytes int
+	ReconnectToken   string
+	Capabilities     []string // negotiated with the server; see protocol.Capability* constants
+}
+
+// ResumedInfo is emitted after the server confirms a session resume (the
+// same SessionID as before the reconnect), as opposed to issuing a fresh
+// one, so the UI can distinguish a true reconnect from a cold start.
+// ReplayedRequestIDs lists the requests whose buffered response the client
+// re-sent to catch the server up.
+type ResumedInfo struct {
+	SessionID          string
+	ReplayedRequestIDs []string
+}
+
+// TcpStreamInfo reports the lifecycle and byte counts of a single relayed
+// raw TCP stream, for display in the TUI.
+type TcpStreamInfo struct {
+	StreamID   string
+	RemoteAddr string
+	BytesIn    int64
+	BytesOut   int64
+	Closed     bool
+}
+
+// CertInfo reports the outcome of an ACME certificate issuance or renewal,
+// emitted alongside TunnelEvent's "cert-issued" and "cert-renewed" types.
+type CertInfo struct {
+	Domain   string
+	NotAfter time.Time
+}
+
+// HeartbeatInfo reports the outcome of one application-layer heartbeat
+// round trip, emitted alongside TunnelEvent's "heartbeat" type.
+type HeartbeatInfo struct {
+	RTT         time.Duration
+	LastPongAge time.Duration
 }
 
 // TunnelEvent is an event emitted by the tunnel client.
@@ -47,35 +129,89 @@ type TunnelEvent struct {
 	Status        TunnelStatus
 	Authenticated *AuthenticatedInfo
 	Traffic       *TrafficEntry
+	Tcp           *TcpStreamInfo
+	Resumed       *ResumedInfo
+	Cert          *CertInfo
+	Heartbeat     *HeartbeatInfo
 	Error         error
 }
 
 // ClientOptions configures a tunnel client.
 type ClientOptions struct {
-	Subdomain string
-	Port      int
-	TTL       int
-	Host      string
-	Domain    string
+	Subdomain              string
+	Port                   int
+	TTL                    int
+	Host                   string
+	Target                 Target // if Target.Host is set, dials it (scheme, host, port, TLS verification) in place of the plain Host/Port
+	Domain                 string
+	Mode                   string        // "tcp" for a raw TCP tunnel, "socks" for an in-process SOCKS5 server; "" (default) is HTTP
+	ReauthorizeInterval    time.Duration // how often to re-validate the session; 0 uses the default
+	HeartbeatInterval      time.Duration // how often to send an application-layer ping; 0 uses the default
+	HeartbeatMissThreshold int           // consecutive unanswered pings before forcing a reconnect; 0 uses the default
+	HarRecorder            *har.Recorder // if set, every proxied HTTP request/response is recorded for capture
+	RecordBodies           bool          // if true, every proxied HTTP exchange is kept in a bounded in-memory ring, retrievable via Client.History() and Client.ExportHAR
+	HistoryEntries         int           // max entries kept when RecordBodies is set; 0 uses defaultHistoryEntries
+	ReconnectToken         string        // server-issued token from a previous session, presented to resume it
+	OnReconnectToken       func(token string)
+	Metrics                *metrics.Registry  // if set, connection/stream/traffic counters are recorded for --metrics
+	ProxyURL               *url.URL           // if set, routes the local target dial through this proxy instead of HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+	Routes                 []Route            // if set, fan out to multiple local backends by path prefix instead of the single Host/Port (see resolveRoute)
+	ResumeBufferEntries    int                // max completed responses kept for replay on resume; 0 uses defaultResumeBufferEntries
+	ResumeBufferBytes      int                // max total bytes of completed responses kept for replay on resume; 0 uses defaultResumeBufferBytes
+	MinCompressSize        int                // minimum known body size, in bytes, worth gzip-compressing over the wire; 0 uses defaultMinCompressSize
+	CustomDomain           string             // a domain the caller owns, routed to this tunnel instead of the default *.xpose.dev subdomain
+	TLSMode                string             // protocol.TLSModeAuto, TLSModeManual, or TLSModeNone (default); auto has the client drive ACME itself
+	AcmeEmail              string             // contact address for the ACME account; optional
+	AcmeDirectoryURL       string             // overrides acme.LetsEncryptDirectoryURL; tests point this at a mock CA
+	TLSConfig              *tls.Config        // client certs and/or a custom root CA pool for the WebSocket dial, for private/self-hosted deployments behind mTLS
+	BearerToken            string             // if set, sent as "Authorization: Bearer <token>" on the dial handshake request
+	HeaderFunc             func() http.Header // if set, called on every dial attempt and merged into the handshake request's headers (e.g. for a short-lived signed header)
+	Subprotocols           []string           // WebSocket subprotocols to offer during the dial, for operators that want to pin a protocol version at the edge
+	FrameMTU               int                // max sub-frame payload size under protocol version 2+; 0 uses protocol.DefaultFrameMTU
 }
 
 // Client manages a WebSocket tunnel connection.
 type Client struct {
-	opts   ClientOptions
-	Events chan TunnelEvent
-	wsURL  string
-
-	mu                     sync.Mutex
-	conn                   *websocket.Conn
-	sessionID              string
-	maxBodySizeBytes       int
-	reconnectAttempts      int
-	disconnectedIntionally bool
-	requestBodyChunks      map[string][][]byte
-	requestBodySizes       map[string]int
-	oversizedRequestIDs    map[string]struct{}
-	pendingRequestMeta     map[string]*protocol.HttpRequestMessage
-	cancelFunc             context.CancelFunc
+	opts         ClientOptions
+	Events       chan TunnelEvent
+	wsURL        string
+	authorizeURL string
+
+	mu                      sync.Mutex
+	conn                    *websocket.Conn
+	sessionID               string
+	sessionFingerprint      string
+	reconnectToken          string
+	negotiatedVersion       int
+	negotiatedCapabilities  []string
+	maxBodySizeBytes        int
+	reconnectAttempts       int
+	disconnectedIntionally  bool
+	requestStreams          map[string]*requestStream
+	activeCancels           map[string]context.CancelFunc
+	responseWindows         map[string]*protocol.Window
+	metricsConnected        bool
+	metricsAuthenticated    bool
+	cancelFunc              context.CancelFunc
+	tcpRelay                *tcpRelayManager
+	wsRelay                 *wsRelayManager
+	resumeBuf               *resumeBuffer
+	history                 *historyBuffer
+	framer                  *protocol.Framer
+	responseSeq             uint64
+	negotiatedBodyEncoding  string
+	negotiatedChallengeHost string
+
+	heartbeatSeq uint64
+	pendingPings map[uint64]time.Time // unanswered heartbeat pings, keyed by seq, for RTT correlation and miss detection
+	lastRTT      time.Duration
+	lastPongAt   time.Time
+
+	acmeResponder *acme.ChallengeResponder // non-nil only when opts.TLSMode == protocol.TLSModeAuto
+	acmeManager   *acme.Manager
+	acmeStarted   bool // set once manageCertificate's renewal loop has been launched, so a later reconnect's auth-ack doesn't start a second one
+
+	lastStatus TunnelStatus // most recently emitted "status" event, so fallbackFromResuming can tell if it's still relevant
 }
 
 // NewClient creates a new tunnel client.
@@ -85,16 +221,209 @@ func NewClient(opts ClientOptions) *Client {
 		domain = protocol.DefaultPublicDomain
 	}
 
-	return &Client{
-		opts:                opts,
-		Events:              make(chan TunnelEvent, 100),
-		wsURL:               fmt.Sprintf("wss://%s.%s%s", opts.Subdomain, domain, protocol.TunnelConnectPath),
-		maxBodySizeBytes:    protocol.DefaultMaxBodySizeBytes,
-		requestBodyChunks:   make(map[string][][]byte),
-		requestBodySizes:    make(map[string]int),
-		oversizedRequestIDs: make(map[string]struct{}),
-		pendingRequestMeta:  make(map[string]*protocol.HttpRequestMessage),
+	c := &Client{
+		opts:             opts,
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            fmt.Sprintf("wss://%s.%s%s", opts.Subdomain, domain, protocol.TunnelConnectPath),
+		authorizeURL:     fmt.Sprintf("https://%s.%s%s", opts.Subdomain, domain, protocol.AuthorizePath),
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		reconnectToken:   opts.ReconnectToken,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+		responseWindows:  make(map[string]*protocol.Window),
+		resumeBuf:        newResumeBuffer(opts.ResumeBufferEntries, opts.ResumeBufferBytes),
+		history:          newHistoryBuffer(opts.HistoryEntries),
+		framer:           protocol.NewFramer(opts.FrameMTU),
+		pendingPings:     make(map[uint64]time.Time),
+	}
+
+	switch opts.Mode {
+	case "tcp", "socks":
+		c.tcpRelay = newTcpRelayManager(opts.Host, opts.Port, opts.Mode)
+	default:
+		c.wsRelay = newWsRelayManager(opts.Host, opts.Port, opts.Metrics)
+	}
+
+	if opts.CustomDomain != "" && opts.TLSMode == protocol.TLSModeAuto {
+		c.acmeResponder = acme.NewChallengeResponder()
+		c.acmeManager = acme.NewManager(opts.CustomDomain, opts.AcmeEmail, c.acmeResponder)
+		if opts.AcmeDirectoryURL != "" {
+			c.acmeManager.DirectoryURL = opts.AcmeDirectoryURL
+		}
+	}
+
+	return c
+}
+
+// reauthorizeInterval returns the configured re-authorization interval, or
+// the default if none was set.
+func (c *Client) reauthorizeInterval() time.Duration {
+	if c.opts.ReauthorizeInterval > 0 {
+		return c.opts.ReauthorizeInterval
+	}
+	return protocol.ReauthorizeIntervalSeconds * time.Second
+}
+
+// heartbeatInterval returns the configured application-layer heartbeat
+// interval, or the default if none was set.
+func (c *Client) heartbeatInterval() time.Duration {
+	if c.opts.HeartbeatInterval > 0 {
+		return c.opts.HeartbeatInterval
+	}
+	return protocol.DefaultHeartbeatIntervalSeconds * time.Second
+}
+
+// heartbeatMissThreshold returns the configured number of consecutive
+// unanswered pings tolerated before the connection is forced closed, or the
+// default if none was set.
+func (c *Client) heartbeatMissThreshold() int {
+	if c.opts.HeartbeatMissThreshold > 0 {
+		return c.opts.HeartbeatMissThreshold
+	}
+	return protocol.DefaultHeartbeatMissThreshold
+}
+
+// Stats is a point-in-time snapshot of runtime metrics useful for display,
+// e.g. by the TUI's tunnel card.
+type Stats struct {
+	RTT         time.Duration // most recent heartbeat round-trip time; zero before the first pong
+	LastPongAge time.Duration // time since the last heartbeat pong was received; zero before the first pong
+}
+
+// Stats returns the client's current heartbeat RTT and staleness.
+func (c *Client) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := Stats{RTT: c.lastRTT}
+	if !c.lastPongAt.IsZero() {
+		stats.LastPongAge = time.Since(c.lastPongAt)
 	}
+	return stats
+}
+
+// History returns the HTTP exchanges recorded since RecordBodies was set,
+// oldest first, up to HistoryEntries. It's empty if ClientOptions.RecordBodies
+// is false, since nothing is recorded in that case.
+func (c *Client) History() []TrafficEntry {
+	return c.history.snapshot()
+}
+
+// ExportHAR serializes History() as a HAR 1.2 document to w, for loading into
+// browser devtools, Insomnia, or any other HAR-compatible tool.
+func (c *Client) ExportHAR(w io.Writer) error {
+	entries := c.History()
+	harEntries := make([]har.Entry, 0, len(entries))
+	for _, t := range entries {
+		target := c.LocalTarget(t.Path)
+		harEntries = append(harEntries, har.NewEntry(har.EntryParams{
+			StartedAt:       t.Timestamp.Add(-t.Duration),
+			Duration:        t.Duration,
+			Method:          t.Method,
+			Host:            fmt.Sprintf("%s:%d", target.Host, target.Port),
+			Path:            t.Path,
+			RequestHeaders:  t.RequestHeaders,
+			RequestBody:     t.RequestBody,
+			Status:          t.Status,
+			ResponseHeaders: t.ResponseHeaders,
+			ResponseBody:    t.ResponseBody,
+			BodyTruncated:   t.BodyTruncated,
+		}))
+	}
+
+	log := har.Log{
+		Log: har.LogBody{
+			Version: "1.2",
+			Creator: har.Creator{Name: "xpose", Version: version.Version},
+			Entries: harEntries,
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// Mode returns the client's tunnel mode, "http", "tcp", or "socks".
+func (c *Client) Mode() string {
+	if c.opts.Mode == "" {
+		return "http"
+	}
+	return c.opts.Mode
+}
+
+// Routes returns the tunnel's configured path-prefix routes, or nil if it
+// was set up with a single Host/Port instead of fanning out to several
+// local backends. Exposed so the TUI can list each route on the tunnel
+// card without reaching into ClientOptions directly.
+func (c *Client) Routes() []Route {
+	return c.opts.Routes
+}
+
+// LocalTarget returns the Target of the local server that would handle
+// path, so callers that need to re-issue a request outside the normal
+// tunnel flow (e.g. the TUI's replay-from-inspector command) can call
+// ProxyRequest directly. It consults opts.Routes first, falling back to
+// opts.Target (if set) or the plain opts.Host/opts.Port when no route
+// matches (or none are configured). A matched route always dials plain
+// http, mirroring resolveDialTarget.
+func (c *Client) LocalTarget(path string) Target {
+	if route, _, ok := resolveRoute(c.opts.Routes, path); ok {
+		return Target{Host: route.Host, Port: route.Port}
+	}
+	if c.opts.Target.Host != "" {
+		return c.opts.Target
+	}
+	return Target{Host: c.opts.Host, Port: c.opts.Port}
+}
+
+// routeTargets converts routes to the wire representation published in a
+// RouteConfigMessage, so the edge can route by longest-prefix match itself
+// instead of every request making a round trip through the client just to
+// get dispatched back out.
+func routeTargets(routes []Route) []protocol.RouteTarget {
+	targets := make([]protocol.RouteTarget, len(routes))
+	for i, r := range routes {
+		targets[i] = protocol.RouteTarget{
+			PathPrefix:  r.PathPrefix,
+			Host:        r.Host,
+			Port:        r.Port,
+			StripPrefix: r.StripPrefix,
+		}
+	}
+	return targets
+}
+
+// resumeBuffer returns the client's resume ring buffer, lazily creating it
+// with the configured (or default) caps. Tests that build a Client literal
+// directly (rather than via NewClient) don't always set resumeBuf.
+func (c *Client) resumeBuffer() *resumeBuffer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumeBuf == nil {
+		c.resumeBuf = newResumeBuffer(c.opts.ResumeBufferEntries, c.opts.ResumeBufferBytes)
+	}
+	return c.resumeBuf
+}
+
+// NegotiatedCapabilities returns the capability set agreed with the server
+// on the most recent auth-ack, so callers can select compression or framer
+// behavior accordingly. Empty (not nil) before the first successful auth.
+func (c *Client) NegotiatedCapabilities() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	caps := make([]string, len(c.negotiatedCapabilities))
+	copy(caps, c.negotiatedCapabilities)
+	return caps
+}
+
+// hasCapability reports whether name appears in caps.
+func hasCapability(caps []string, name string) bool {
+	for _, c := range caps {
+		if c == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Connect starts the WebSocket connection. Non-blocking.
@@ -116,11 +445,22 @@ func (c *Client) Disconnect() {
 	if cancel != nil {
 		cancel()
 	}
+	if c.tcpRelay != nil {
+		c.tcpRelay.closeAll()
+	}
+	if c.wsRelay != nil {
+		c.wsRelay.closeAll()
+	}
 
 	c.emit(TunnelEvent{Type: "status", Status: StatusDisconnected})
 }
 
 func (c *Client) emit(ev TunnelEvent) {
+	if ev.Type == "status" {
+		c.mu.Lock()
+		c.lastStatus = ev.Status
+		c.mu.Unlock()
+	}
 	select {
 	case c.Events <- ev:
 	default:
@@ -128,6 +468,77 @@ func (c *Client) emit(ev TunnelEvent) {
 	}
 }
 
+// setMetricsConnected updates the shared metrics.Registry's connected-tunnel
+// gauge, de-duping repeated calls with the same value so reconnect churn
+// can't drift the count.
+func (c *Client) setMetricsConnected(connected bool) {
+	if c.opts.Metrics == nil {
+		return
+	}
+	c.mu.Lock()
+	was := c.metricsConnected
+	c.metricsConnected = connected
+	c.mu.Unlock()
+
+	if connected && !was {
+		c.opts.Metrics.IncConnectedTunnels()
+	} else if !connected && was {
+		c.opts.Metrics.DecConnectedTunnels()
+	}
+	c.opts.Metrics.SetTunnelUp(c.opts.Subdomain, c.opts.Port, connected)
+}
+
+// setMetricsAuthenticated is setMetricsConnected's counterpart for the
+// authenticated-tunnel gauge used by /readyz.
+func (c *Client) setMetricsAuthenticated(authenticated bool) {
+	if c.opts.Metrics == nil {
+		return
+	}
+	c.mu.Lock()
+	was := c.metricsAuthenticated
+	c.metricsAuthenticated = authenticated
+	c.mu.Unlock()
+
+	if authenticated && !was {
+		c.opts.Metrics.IncAuthenticatedTunnels()
+	} else if !authenticated && was {
+		c.opts.Metrics.DecAuthenticatedTunnels()
+	}
+}
+
+// dialOptions builds the websocket.DialOptions for the WebSocket handshake
+// from TLSConfig, BearerToken, HeaderFunc, and Subprotocols, or returns nil
+// if none of them are set so the dial behaves exactly as it did before they
+// existed.
+func (c *Client) dialOptions() *websocket.DialOptions {
+	if c.opts.TLSConfig == nil && c.opts.BearerToken == "" && c.opts.HeaderFunc == nil && len(c.opts.Subprotocols) == 0 {
+		return nil
+	}
+
+	opts := &websocket.DialOptions{Subprotocols: c.opts.Subprotocols}
+
+	if c.opts.HeaderFunc != nil {
+		opts.HTTPHeader = c.opts.HeaderFunc()
+	}
+	if opts.HTTPHeader == nil {
+		opts.HTTPHeader = http.Header{}
+	}
+	if c.opts.BearerToken != "" {
+		opts.HTTPHeader.Set("Authorization", "Bearer "+c.opts.BearerToken)
+	}
+
+	if c.opts.TLSConfig != nil {
+		// Clone DefaultTransport rather than building one from scratch so the
+		// dial still honors HTTP_PROXY/HTTPS_PROXY/NO_PROXY like the default
+		// client would have.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = c.opts.TLSConfig
+		opts.HTTPClient = &http.Client{Transport: transport}
+	}
+
+	return opts
+}
+
 func (c *Client) connectLoop() {
 	c.mu.Lock()
 	status := StatusConnecting
@@ -145,13 +556,28 @@ func (c *Client) connectLoop() {
 	c.mu.Unlock()
 	defer cancel()
 
-	conn, _, err := websocket.Dial(ctx, c.wsURL, nil)
+	if c.opts.Metrics != nil {
+		c.opts.Metrics.IncConnectAttempts()
+	}
+
+	conn, resp, err := websocket.Dial(ctx, c.wsURL, c.dialOptions())
 	if err != nil {
 		c.mu.Lock()
 		intentional := c.disconnectedIntionally
 		c.mu.Unlock()
 
 		if !intentional {
+			if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+				// The edge rejected the dial itself (bad bearer token, no
+				// client cert, ...) rather than the tunnel protocol rejecting
+				// the session; retrying won't help until the caller fixes
+				// its credentials, so surface this distinctly and stop like
+				// the session-level 401 case below does.
+				c.emit(TunnelEvent{Type: "auth_error", Error: fmt.Errorf("dial rejected: %w", err)})
+				c.emit(TunnelEvent{Type: "status", Status: StatusExpired})
+				c.emit(TunnelEvent{Type: "expired"})
+				return
+			}
 			c.emit(TunnelEvent{Type: "error", Error: fmt.Errorf("dial failed: %w", err)})
 			c.scheduleReconnect()
 		}
@@ -165,14 +591,22 @@ func (c *Client) connectLoop() {
 	c.conn = conn
 	c.reconnectAttempts = 0
 	c.mu.Unlock()
+	c.setMetricsConnected(true)
 
 	// Send auth message
 	c.mu.Lock()
 	authMsg := protocol.AuthMessage{
-		Type:      "auth",
-		Subdomain: c.opts.Subdomain,
-		TTL:       c.opts.TTL,
-		SessionID: c.sessionID,
+		Type:              "auth",
+		Subdomain:         c.opts.Subdomain,
+		TTL:               c.opts.TTL,
+		SessionID:         c.sessionID,
+		Mode:              c.opts.Mode,
+		ReconnectToken:    c.reconnectToken,
+		ProtocolVersion:   protocol.ProtocolVersion,
+		Capabilities:      protocol.ClientCapabilities,
+		AcceptedEncodings: protocol.ClientAcceptedEncodings,
+		CustomDomain:      c.opts.CustomDomain,
+		TLSMode:           c.opts.TLSMode,
 	}
 	c.mu.Unlock()
 
@@ -180,6 +614,8 @@ func (c *Client) connectLoop() {
 	if err := conn.Write(ctx, websocket.MessageText, authData); err != nil {
 		c.emit(TunnelEvent{Type: "error", Error: fmt.Errorf("failed to send auth: %w", err)})
 		conn.Close(websocket.StatusInternalError, "auth failed")
+		c.setMetricsConnected(false)
+		c.setMetricsAuthenticated(false)
 		c.scheduleReconnect()
 		return
 	}
@@ -188,6 +624,9 @@ func (c *Client) connectLoop() {
 	for {
 		msgType, data, err := conn.Read(ctx)
 		if err != nil {
+			c.setMetricsConnected(false)
+			c.setMetricsAuthenticated(false)
+
 			c.mu.Lock()
 			intentional := c.disconnectedIntionally
 			c.mu.Unlock()
@@ -202,26 +641,52 @@ func (c *Client) connectLoop() {
 		case websocket.MessageText:
 			c.handleTextMessage(ctx, conn, data)
 		case websocket.MessageBinary:
-			c.handleBinaryFrame(data)
+			c.handleBinaryFrame(ctx, conn, data)
 		}
 	}
 }
 
 func (c *Client) handleTextMessage(ctx context.Context, conn *websocket.Conn, data []byte) {
 	parsed, err := protocol.ParseTextMessage(data)
-	if err != nil || parsed == nil {
+	if err != nil {
+		return
+	}
+	if parsed == nil {
+		if c.opts.Metrics != nil {
+			c.opts.Metrics.IncUnknownMessage()
+		}
 		return
 	}
 
 	switch msg := parsed.(type) {
 	case *protocol.AuthAckMessage:
 		c.mu.Lock()
+		prevSessionID := c.sessionID
 		c.sessionID = msg.SessionID
+		c.sessionFingerprint = msg.SessionFingerprint
 		c.maxBodySizeBytes = msg.MaxBodySizeBytes
+		c.negotiatedVersion = msg.ProtocolVersion
+		c.negotiatedCapabilities = msg.Capabilities
+		c.negotiatedBodyEncoding = msg.BodyEncoding
+		c.negotiatedChallengeHost = msg.ChallengeHost
+		if msg.ReconnectToken != "" {
+			c.reconnectToken = msg.ReconnectToken
+		}
 		conn.SetReadLimit(int64(c.maxBodySizeBytes) + int64(protocol.RequestIDLength) + 1024)
 		c.mu.Unlock()
+		c.setMetricsAuthenticated(true)
+		if c.opts.Metrics != nil {
+			c.opts.Metrics.SetTunnelDeadline(c.opts.Subdomain, time.Now().Add(time.Duration(msg.RemainingTTL)*time.Second))
+		}
+
+		if c.acmeManager != nil {
+			go c.manageCertificate(ctx)
+		}
+
+		if msg.ReconnectToken != "" && c.opts.OnReconnectToken != nil {
+			c.opts.OnReconnectToken(msg.ReconnectToken)
+		}
 
-		c.emit(TunnelEvent{Type: "status", Status: StatusConnected})
 		c.emit(TunnelEvent{
 			Type: "authenticated",
 			Authenticated: &AuthenticatedInfo{
@@ -229,10 +694,61 @@ func (c *Client) handleTextMessage(ctx context.Context, conn *websocket.Conn, da
 				TTL:              msg.TTL,
 				SessionID:        msg.SessionID,
 				MaxBodySizeBytes: msg.MaxBodySizeBytes,
+				ReconnectToken:   msg.ReconnectToken,
+				Capabilities:     msg.Capabilities,
+			},
+		})
+
+		go c.reauthLoop(ctx, conn)
+		go c.heartbeatLoop(ctx, conn)
+
+		if len(c.opts.Routes) > 1 {
+			c.sendJSON(ctx, conn, &protocol.RouteConfigMessage{
+				Type:   "route-config",
+				Routes: routeTargets(c.opts.Routes),
+			})
+		}
+
+		canResume := hasCapability(msg.Capabilities, protocol.CapabilitySessionResume)
+		if canResume && prevSessionID != "" && prevSessionID == msg.SessionID {
+			// Hold off on "connected" until the server's ResumeAckMessage
+			// confirms our buffered responses were replayed, so the TUI can
+			// distinguish "dialed back in" from "actually caught up".
+			c.emit(TunnelEvent{Type: "status", Status: StatusResuming})
+			c.sendJSON(ctx, conn, &protocol.ResumeMessage{
+				Type:                "resume",
+				SessionID:           msg.SessionID,
+				LastAckedRequestSeq: c.resumeBuffer().highestSeq(),
+			})
+			go c.fallbackFromResuming(ctx)
+		} else {
+			c.resumeBuffer().reset()
+			c.emit(TunnelEvent{Type: "status", Status: StatusConnected})
+		}
+
+	case *protocol.ResumeAckMessage:
+		for _, rec := range c.resumeBuffer().replayFor(msg.ResumedRequestIDs) {
+			if err := conn.Write(ctx, websocket.MessageText, rec.Data); err != nil {
+				return
+			}
+		}
+		c.emit(TunnelEvent{Type: "status", Status: StatusConnected})
+		c.emit(TunnelEvent{
+			Type: "resumed",
+			Resumed: &ResumedInfo{
+				SessionID:          msg.SessionID,
+				ReplayedRequestIDs: msg.ResumedRequestIDs,
 			},
 		})
 
 	case *protocol.HttpRequestMessage:
+		if c.acmeResponder != nil {
+			if keyAuth, ok := c.acmeResponder.Respond(msg.Path); ok {
+				c.respondACMEChallenge(ctx, conn, msg.ID, keyAuth)
+				return
+			}
+		}
+
 		c.mu.Lock()
 		maxBody := c.maxBodySizeBytes
 		c.mu.Unlock()
@@ -241,7 +757,7 @@ func (c *Client) handleTextMessage(ctx context.Context, conn *websocket.Conn, da
 		if cl, ok := caseInsensitiveGet(msg.Headers, "content-length"); ok {
 			var size int
 			if _, err := fmt.Sscanf(cl, "%d", &size); err == nil && size > maxBody {
-				c.sendJSON(ctx, conn, &protocol.ErrorMessage{
+				c.sendFinalResponse(ctx, conn, msg.ID, &protocol.ErrorMessage{
 					Type:      "error",
 					Message:   fmt.Sprintf("Request body exceeds %d byte limit", maxBody),
 					RequestID: msg.ID,
@@ -251,184 +767,576 @@ func (c *Client) handleTextMessage(ctx context.Context, conn *websocket.Conn, da
 			}
 		}
 
+		reqCtx, cancel := context.WithCancel(ctx)
+		c.mu.Lock()
+		c.activeCancels[msg.ID] = cancel
+		c.mu.Unlock()
+
 		if msg.HasBody {
+			pr, pw := io.Pipe()
+			rs := newRequestStream(pw, maxBody)
+
 			c.mu.Lock()
-			c.requestBodyChunks[msg.ID] = [][]byte{}
-			c.requestBodySizes[msg.ID] = 0
-			c.pendingRequestMeta[msg.ID] = msg
+			c.requestStreams[msg.ID] = rs
 			c.mu.Unlock()
+
+			go c.pumpRequestBody(ctx, conn, msg.ID, rs)
+			c.sendJSON(ctx, conn, &protocol.HttpFlowControlMessage{
+				Type:            "http-flow-control",
+				ID:              msg.ID,
+				WindowIncrement: rs.granted,
+			})
+
+			go c.handleHTTPRequest(reqCtx, conn, msg, pr)
 		} else {
-			c.handleHTTPRequest(ctx, conn, msg, nil)
+			go c.handleHTTPRequest(reqCtx, conn, msg, nil)
 		}
 
 	case *protocol.HttpRequestEndMessage:
 		c.mu.Lock()
-		chunks := c.requestBodyChunks[msg.ID]
-		delete(c.requestBodyChunks, msg.ID)
-		reqMeta := c.pendingRequestMeta[msg.ID]
-		delete(c.pendingRequestMeta, msg.ID)
-		delete(c.requestBodySizes, msg.ID)
-		_, oversized := c.oversizedRequestIDs[msg.ID]
-		delete(c.oversizedRequestIDs, msg.ID)
-		maxBody := c.maxBodySizeBytes
+		rs, exists := c.requestStreams[msg.ID]
+		delete(c.requestStreams, msg.ID)
 		c.mu.Unlock()
 
-		if reqMeta == nil {
-			return
+		if exists {
+			rs.closeChunks()
 		}
 
-		if oversized {
-			c.sendJSON(ctx, conn, &protocol.ErrorMessage{
-				Type:      "error",
-				Message:   fmt.Sprintf("Request body exceeds %d byte limit", maxBody),
-				RequestID: msg.ID,
-				Status:    413,
-			})
-			return
+	case *protocol.HttpBodyChunkMessage:
+		// Binary data follows, handled in handleBinaryFrame. Encoding names
+		// the wire codec the peer used for that data; trust it rather than
+		// re-deriving our own guess, since only the sender actually knows
+		// whether it compressed this body.
+		if msg.Encoding != "" {
+			c.mu.Lock()
+			rs := c.requestStreams[msg.ID]
+			maxBody := c.maxBodySizeBytes
+			c.mu.Unlock()
+			if rs != nil {
+				rs.setEncoding(msg.Encoding, maxBody)
+			}
 		}
 
-		body := concatChunks(chunks)
-		var bodyPtr []byte
-		if len(body) > 0 {
-			bodyPtr = body
+	case *protocol.HttpCancelMessage:
+		reason := msg.Reason
+		if reason == "" {
+			reason = "canceled by peer"
 		}
-		c.handleHTTPRequest(ctx, conn, reqMeta, bodyPtr)
-
-	case *protocol.HttpBodyChunkMessage:
-		// Binary data follows, handled in handleBinaryFrame
+		c.abortRequestStream(msg.ID, reason)
 
 	case *protocol.ErrorMessage:
-		if msg.Message == "Tunnel TTL expired" {
+		switch {
+		case msg.Message == "Tunnel TTL expired":
 			c.emit(TunnelEvent{Type: "status", Status: StatusExpired})
 			c.emit(TunnelEvent{Type: "expired"})
-		} else {
+		case msg.RequestID == "" && msg.Status == protocol.StatusProtocolVersionUnsupported:
+			// Server and client share no usable protocol version; retrying
+			// won't help until one side is upgraded.
+			c.emit(TunnelEvent{Type: "error", Error: fmt.Errorf("%s", msg.Message)})
+			c.emit(TunnelEvent{Type: "status", Status: StatusExpired})
+			c.emit(TunnelEvent{Type: "expired"})
+		case msg.RequestID == "" && msg.Status == 401:
+			// Rejected at the session/auth level (e.g. an expired or
+			// mismatched reconnect token), not a per-request error: treat it
+			// like expiry so the CLI doesn't keep retrying a dead session.
+			c.mu.Lock()
+			c.reconnectToken = ""
+			c.mu.Unlock()
+			c.emit(TunnelEvent{Type: "status", Status: StatusExpired})
+			c.emit(TunnelEvent{Type: "expired"})
+		default:
 			c.emit(TunnelEvent{Type: "error", Error: fmt.Errorf("%s", msg.Message)})
 		}
 
 	case *protocol.PingMsg:
-		c.sendJSON(ctx, conn, &protocol.PongMsg{Type: "pong"})
+		c.sendJSON(ctx, conn, &protocol.PongMsg{Type: "pong", Seq: msg.Seq})
+
+	case *protocol.PongMsg:
+		c.handleHeartbeatPong(msg)
+
+	case *protocol.TcpOpenMessage:
+		if c.tcpRelay != nil {
+			c.tcpRelay.handleOpen(ctx, conn, msg, c.sendJSON, c.emitTcpStream)
+		}
+
+	case *protocol.TcpCloseMessage:
+		if c.tcpRelay != nil {
+			c.tcpRelay.handleClose(msg, c.emitTcpStream)
+		}
 	}
 }
 
-func (c *Client) handleBinaryFrame(data []byte) {
+// emitTcpStream publishes a TCP stream lifecycle/byte-count update as a
+// tunnel event for the TUI to render.
+func (c *Client) emitTcpStream(info TcpStreamInfo) {
+	c.emit(TunnelEvent{Type: "tcp-stream", Tcp: &info})
+}
+
+func (c *Client) handleBinaryFrame(ctx context.Context, conn *websocket.Conn, data []byte) {
+	if c.tcpRelay != nil {
+		streamID, body, err := protocol.DecodeBinaryFrame(data)
+		if err != nil {
+			return
+		}
+		c.tcpRelay.handleData(streamID, body, c.emitTcpStream)
+		return
+	}
+
+	c.mu.Lock()
+	version := c.negotiatedVersion
+	c.mu.Unlock()
+
+	if version >= 2 {
+		frames, err := protocol.DecodeFrames(data)
+		if err != nil {
+			return
+		}
+		for _, f := range frames {
+			c.handleFrame(ctx, conn, f)
+		}
+		return
+	}
+
 	requestID, body, err := protocol.DecodeBinaryFrame(data)
 	if err != nil {
 		return
 	}
+	c.handleRequestBodyChunk(ctx, conn, requestID, body)
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// handleFrame dispatches a single decoded protocol version 2+ frame: a
+// WINDOW_UPDATE replenishes the matching response stream's send credit, a
+// RESET aborts any in-flight request stream, and anything else is a request
+// body chunk.
+func (c *Client) handleFrame(ctx context.Context, conn *websocket.Conn, f protocol.Frame) {
+	if f.Flags&protocol.FlagWindowUpdate != 0 {
+		credit, err := protocol.WindowUpdateCredit(f)
+		if err != nil {
+			return
+		}
+		c.mu.Lock()
+		win := c.responseWindows[f.RequestID]
+		c.mu.Unlock()
+		if win != nil {
+			win.Replenish(int(credit))
+		}
+		return
+	}
+
+	if f.Flags&protocol.FlagReset != 0 {
+		c.abortRequestStream(f.RequestID, "reset by peer")
+		return
+	}
+
+	c.handleRequestBodyChunk(ctx, conn, f.RequestID, f.Payload)
+}
 
-	chunks, exists := c.requestBodyChunks[requestID]
+// handleRequestBodyChunk forwards an inbound request body chunk to its
+// requestStream. If the stream has already been marked oversized or torn
+// down, the chunk is dropped, since handleRequestBodyChunk or
+// abortRequestStream already reported the error upstream.
+func (c *Client) handleRequestBodyChunk(ctx context.Context, conn *websocket.Conn, requestID string, body []byte) {
+	c.mu.Lock()
+	rs, exists := c.requestStreams[requestID]
+	c.mu.Unlock()
 	if !exists {
 		return
 	}
-	if _, oversized := c.oversizedRequestIDs[requestID]; oversized {
+
+	rs.mu.Lock()
+	oversized := rs.oversized
+	rs.mu.Unlock()
+	if oversized {
 		return
 	}
 
-	nextSize := c.requestBodySizes[requestID] + len(body)
-	c.requestBodySizes[requestID] = nextSize
+	if err := rs.accept(ctx, body); err != nil {
+		rs.mu.Lock()
+		rs.oversized = true
+		rs.mu.Unlock()
 
-	if nextSize > c.maxBodySizeBytes {
-		c.oversizedRequestIDs[requestID] = struct{}{}
-		delete(c.requestBodyChunks, requestID)
+		c.sendJSON(ctx, conn, &protocol.HttpBodyErrorMessage{
+			Type:      "http-body-error",
+			ID:        requestID,
+			Message:   err.Error(),
+			Retryable: false,
+		})
+		c.abortRequestStream(requestID, err.Error())
 		return
 	}
 
-	c.requestBodyChunks[requestID] = append(chunks, body)
+	if c.opts.Metrics != nil {
+		c.opts.Metrics.AddBytesIn(len(body))
+	}
+}
+
+// resolveDialTarget picks the local backend for msg, consulting opts.Routes
+// first and falling back to opts.Target (if set) or the plain opts.Host/
+// opts.Port when no route matches (or none are configured). It returns msg
+// unchanged unless the matched route strips its prefix, in which case it
+// returns a shallow copy with Path rewritten so handlers further down don't
+// see the stripped path leak back into TrafficEntry.Path. A matched route
+// always dials plain http; Target's scheme/TLS settings apply only to the
+// single-backend fallback.
+func (c *Client) resolveDialTarget(msg *protocol.HttpRequestMessage) (target Target, routeLabel string, dialMsg *protocol.HttpRequestMessage) {
+	route, forwardPath, ok := resolveRoute(c.opts.Routes, msg.Path)
+	if !ok {
+		if c.opts.Target.Host != "" {
+			return c.opts.Target, "", msg
+		}
+		return Target{Host: c.opts.Host, Port: c.opts.Port}, "", msg
+	}
+
+	dialMsg = msg
+	if forwardPath != msg.Path {
+		rewritten := *msg
+		rewritten.Path = forwardPath
+		dialMsg = &rewritten
+	}
+	return Target{Host: route.Host, Port: route.Port}, route.PathPrefix, dialMsg
 }
 
-func (c *Client) handleHTTPRequest(ctx context.Context, conn *websocket.Conn, msg *protocol.HttpRequestMessage, body []byte) {
+func (c *Client) handleHTTPRequest(ctx context.Context, conn *websocket.Conn, msg *protocol.HttpRequestMessage, body io.Reader) {
 	startTime := time.Now()
 
+	defer func() {
+		c.mu.Lock()
+		delete(c.activeCancels, msg.ID)
+		delete(c.requestStreams, msg.ID)
+		c.mu.Unlock()
+	}()
+
+	if c.opts.Metrics != nil {
+		c.opts.Metrics.IncActiveHTTPStreams()
+		defer c.opts.Metrics.DecActiveHTTPStreams()
+		c.opts.Metrics.IncActiveStreams(c.opts.Subdomain)
+		defer c.opts.Metrics.DecActiveStreams(c.opts.Subdomain)
+	}
+
 	c.mu.Lock()
 	maxBody := c.maxBodySizeBytes
 	c.mu.Unlock()
 
-	resp, err := ProxyRequest(c.opts.Host, c.opts.Port, msg, body, maxBody)
+	var reqCapture *har.CaptureReader
+	if body != nil {
+		reqCapture = har.NewCaptureReader(body)
+		body = reqCapture
+	}
+
+	target, routeLabel, dialMsg := c.resolveDialTarget(msg)
+
+	resp, err := ProxyRequest(ctx, target.Host, target.Port, dialMsg, body, maxBody, false, c.opts.ProxyURL, target.Scheme, target.InsecureSkipVerify)
 	duration := time.Since(startTime)
 
 	if err != nil {
+		if ctx.Err() != nil {
+			// The request was canceled, either by us (local body error) or by
+			// the peer (HttpCancelMessage); whoever canceled it already knows
+			// why, so there's nothing useful to report back.
+			return
+		}
+
 		status := 502
-		errMsg := fmt.Sprintf("Failed to reach localhost:%d: %s", c.opts.Port, err.Error())
+		errMsg := fmt.Sprintf("Failed to reach %s:%d: %s", target.Host, target.Port, err.Error())
 
 		if _, ok := err.(*BodyTooLargeError); ok {
 			status = 413
 			errMsg = fmt.Sprintf("Response body exceeds %d byte limit", maxBody)
 		}
 
-		c.sendJSON(ctx, conn, &protocol.ErrorMessage{
+		c.sendFinalResponse(ctx, conn, msg.ID, &protocol.ErrorMessage{
 			Type:      "error",
 			Message:   errMsg,
 			RequestID: msg.ID,
 			Status:    status,
 		})
 
+		entry := &TrafficEntry{
+			ID:             msg.ID,
+			Method:         msg.Method,
+			Path:           msg.Path,
+			Status:         status,
+			Duration:       duration,
+			Timestamp:      time.Now(),
+			RequestHeaders: msg.Headers,
+			Route:          routeLabel,
+		}
+		if reqCapture != nil {
+			entry.RequestBody = reqCapture.Bytes()
+			entry.BodyTruncated = reqCapture.Truncated()
+		}
 		c.emit(TunnelEvent{
-			Type: "traffic",
-			Traffic: &TrafficEntry{
-				ID:        msg.ID,
-				Method:    msg.Method,
-				Path:      msg.Path,
-				Status:    status,
-				Duration:  duration,
-				Timestamp: time.Now(),
-			},
+			Type:    "traffic",
+			Traffic: entry,
 		})
+		if c.opts.RecordBodies {
+			c.history.record(*entry)
+		}
+		if c.opts.Metrics != nil {
+			c.opts.Metrics.IncStatusCode(status)
+			c.opts.Metrics.ObserveRequestDuration(duration.Seconds())
+			c.opts.Metrics.IncRequestTotal(c.opts.Subdomain, msg.Method, status)
+		}
 		return
 	}
+	defer resp.Body.Close()
 
-	hasBody := len(resp.Body) > 0
-
-	// Send response metadata
+	// Send response metadata. The body is streamed below, so HasBody reflects
+	// that a body *may* follow rather than a known non-zero length.
 	c.sendJSON(ctx, conn, &protocol.HttpResponseMetaMessage{
 		Type:    "http-response-meta",
 		ID:      msg.ID,
 		Status:  resp.Status,
 		Headers: resp.Headers,
-		HasBody: hasBody,
+		HasBody: true,
+	})
+
+	// Pump the response body into chunk messages as it arrives, so SSE,
+	// long-polling, and large downloads don't have to buffer fully first.
+	// maxBody is enforced here as a total-bytes cap across the whole stream.
+	capture := har.NewCaptureReader(resp.Body)
+
+	encoding := ""
+	c.mu.Lock()
+	negotiated := c.negotiatedBodyEncoding
+	c.mu.Unlock()
+	if negotiated == "gzip" && !resp.Decoded && bodyEligibleForCompress(resp.Headers, c.minCompressSize()) {
+		encoding = "gzip"
+	}
+
+	_, aborted := c.streamResponseBody(ctx, conn, msg.ID, capture, maxBody, encoding)
+
+	if !aborted {
+		c.sendFinalResponse(ctx, conn, msg.ID, &protocol.HttpResponseEndMessage{
+			Type: "http-response-end",
+			ID:   msg.ID,
+		})
+	}
+
+	duration = time.Since(startTime)
+
+	entry := &TrafficEntry{
+		ID:              msg.ID,
+		Method:          msg.Method,
+		Path:            msg.Path,
+		Status:          resp.Status,
+		Duration:        duration,
+		Timestamp:       time.Now(),
+		RequestHeaders:  msg.Headers,
+		ResponseHeaders: resp.Headers,
+		ResponseBody:    capture.Bytes(),
+		BodyTruncated:   capture.Truncated(),
+		Route:           routeLabel,
+	}
+	if reqCapture != nil {
+		entry.RequestBody = reqCapture.Bytes()
+		if reqCapture.Truncated() {
+			entry.BodyTruncated = true
+		}
+	}
+	c.emit(TunnelEvent{
+		Type:    "traffic",
+		Traffic: entry,
 	})
+	if c.opts.RecordBodies {
+		c.history.record(*entry)
+	}
+	if c.opts.Metrics != nil {
+		c.opts.Metrics.IncStatusCode(resp.Status)
+		c.opts.Metrics.ObserveRequestDuration(duration.Seconds())
+		c.opts.Metrics.IncRequestTotal(c.opts.Subdomain, msg.Method, resp.Status)
+	}
 
-	// Send body chunks
-	if hasBody {
-		chunkSize := 64 * 1024 // 64KB chunks
-		for offset := 0; offset < len(resp.Body); offset += chunkSize {
-			end := offset + chunkSize
-			if end > len(resp.Body) {
-				end = len(resp.Body)
+	if c.opts.HarRecorder != nil {
+		var reqBody []byte
+		if reqCapture != nil {
+			reqBody = reqCapture.Bytes()
+		}
+		c.opts.HarRecorder.Record(har.NewEntry(har.EntryParams{
+			StartedAt:       startTime,
+			Duration:        duration,
+			Method:          msg.Method,
+			Host:            fmt.Sprintf("%s:%d", target.Host, target.Port),
+			Path:            msg.Path,
+			RequestHeaders:  msg.Headers,
+			RequestBody:     reqBody,
+			Status:          resp.Status,
+			ResponseHeaders: resp.Headers,
+			ResponseBody:    capture.Bytes(),
+			BodyTruncated:   capture.Truncated(),
+		}))
+	}
+}
+
+// streamResponseBody copies src into http-body-chunk + binary frame pairs
+// using a pooled buffer, aborting with an error frame if the total bytes
+// read exceed maxBody. Under protocol version 2+, each chunk also consumes
+// flow-control credit from a per-request Window before it's sent, so a
+// server that's slow to drain a download can throttle us via
+// WINDOW_UPDATE frames instead of the chunk just piling up in its own
+// buffers. When encoding is "gzip", each read from src is gzip-compressed
+// and flushed independently before being framed, so the peer can decode the
+// resulting continuous gzip stream incrementally as frames arrive; maxBody
+// is still enforced against the uncompressed byte count read from src. It
+// returns the number of (uncompressed) bytes read and whether the stream
+// was aborted.
+func (c *Client) streamResponseBody(ctx context.Context, conn *websocket.Conn, requestID string, src io.Reader, maxBody int, encoding string) (int, bool) {
+	bufPtr := chunkBufPool.Get().(*[]byte)
+	defer chunkBufPool.Put(bufPtr)
+	buf := *bufPtr
+
+	framer := c.framer
+	if framer == nil {
+		framer = protocol.NewFramer(0)
+	}
+
+	c.mu.Lock()
+	version := c.negotiatedVersion
+	c.mu.Unlock()
+
+	var win *protocol.Window
+	var seq uint32
+	if version >= 2 {
+		win = protocol.NewWindow(protocol.DefaultWindowSizeBytes)
+		c.mu.Lock()
+		c.responseWindows[requestID] = win
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.responseWindows, requestID)
+			c.mu.Unlock()
+		}()
+	}
+
+	var gz *gzip.Writer
+	var gzBuf bytes.Buffer
+	if encoding == "gzip" {
+		gz = gzip.NewWriter(&gzBuf)
+	}
+
+	sendPayload := func(payload []byte) error {
+		c.sendJSON(ctx, conn, &protocol.HttpBodyChunkMessage{
+			Type:     "http-body-chunk",
+			ID:       requestID,
+			Done:     false,
+			Encoding: encoding,
+		})
+
+		var frame []byte
+		if win != nil {
+			if err := win.Consume(ctx, len(payload)); err != nil {
+				return err
 			}
-			chunk := resp.Body[offset:end]
+			var frames []protocol.Frame
+			frames, seq = framer.Split(requestID, payload, seq, false)
+			frame = framer.Pack(frames)
+		} else {
+			frame = protocol.EncodeBinaryFrame(requestID, payload)
+		}
+		return conn.Write(ctx, websocket.MessageBinary, frame)
+	}
 
-			c.sendJSON(ctx, conn, &protocol.HttpBodyChunkMessage{
-				Type: "http-body-chunk",
-				ID:   msg.ID,
-				Done: false,
-			})
+	written := 0
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			written += n
+			if written > maxBody {
+				c.sendFinalResponse(ctx, conn, requestID, &protocol.ErrorMessage{
+					Type:      "error",
+					Message:   fmt.Sprintf("Response body exceeds %d byte limit", maxBody),
+					RequestID: requestID,
+					Status:    413,
+				})
+				return written, true
+			}
 
-			frame := protocol.EncodeBinaryFrame(msg.ID, chunk)
-			if err := conn.Write(ctx, websocket.MessageBinary, frame); err != nil {
-				return
+			if c.opts.Metrics != nil {
+				c.opts.Metrics.AddBytesOut(n)
+			}
+
+			payload := buf[:n]
+			if gz != nil {
+				gzBuf.Reset()
+				if _, err := gz.Write(buf[:n]); err != nil {
+					return written, true
+				}
+				if err := gz.Flush(); err != nil {
+					return written, true
+				}
+				payload = gzBuf.Bytes()
+			}
+
+			if err := sendPayload(payload); err != nil {
+				return written, true
 			}
 		}
+
+		if readErr != nil {
+			if readErr != io.EOF {
+				// The local HTTP response body broke mid-stream: tell the peer
+				// to cancel this request rather than leaving it waiting on a
+				// http-response-end that will never arrive.
+				c.sendJSON(ctx, conn, &protocol.HttpCancelMessage{
+					Type:   "http-cancel",
+					ID:     requestID,
+					Reason: fmt.Sprintf("error reading response body: %s", readErr.Error()),
+				})
+				return written, true
+			}
+
+			if gz != nil {
+				gzBuf.Reset()
+				if err := gz.Close(); err != nil {
+					return written, true
+				}
+				if gzBuf.Len() > 0 {
+					if err := sendPayload(gzBuf.Bytes()); err != nil {
+						return written, true
+					}
+				}
+			}
+			return written, false
+		}
 	}
+}
 
-	// Send response end
-	c.sendJSON(ctx, conn, &protocol.HttpResponseEndMessage{
-		Type: "http-response-end",
-		ID:   msg.ID,
-	})
+// sendFinalResponse marshals and sends a terminal per-request message
+// (http-response-end on success, or error on failure), recording it in the
+// resume buffer under the next response sequence number so it can be
+// replayed if the connection drops before the server processes it.
+func (c *Client) sendFinalResponse(ctx context.Context, conn *websocket.Conn, requestID string, msg any) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+		return
+	}
 
-	c.emit(TunnelEvent{
-		Type: "traffic",
-		Traffic: &TrafficEntry{
-			ID:        msg.ID,
-			Method:    msg.Method,
-			Path:      msg.Path,
-			Status:    resp.Status,
-			Duration:  duration,
-			Timestamp: time.Now(),
-		},
+	c.mu.Lock()
+	c.responseSeq++
+	seq := c.responseSeq
+	c.mu.Unlock()
+
+	c.resumeBuffer().record(responseRecord{Seq: seq, RequestID: requestID, Data: data})
+}
+
+// respondACMEChallenge answers an ACME http-01 validation request directly
+// with keyAuth, bypassing the local backend entirely: the CA is asking
+// xpose's own account key to prove domain ownership, not the user's app.
+func (c *Client) respondACMEChallenge(ctx context.Context, conn *websocket.Conn, requestID, keyAuth string) {
+	c.sendJSON(ctx, conn, &protocol.HttpResponseMetaMessage{
+		Type:    "http-response-meta",
+		ID:      requestID,
+		Status:  200,
+		Headers: map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+		HasBody: true,
+	})
+	c.streamResponseBody(ctx, conn, requestID, strings.NewReader(keyAuth), len(keyAuth)+1, "")
+	c.sendFinalResponse(ctx, conn, requestID, &protocol.HttpResponseEndMessage{
+		Type: "http-response-end",
+		ID:   requestID,
 	})
 }
 
@@ -442,6 +1350,268 @@ func (c *Client) sendJSON(ctx context.Context, conn *websocket.Conn, msg any) {
 	}
 }
 
+// reauthLoop periodically re-validates the tunnel session against
+// /authorize for as long as ctx (the current connection's lifetime) is
+// alive, tearing the connection down via forceReconnect on rejection or a
+// session fingerprint change.
+func (c *Client) reauthLoop(ctx context.Context, conn *websocket.Conn) {
+	ticker := time.NewTicker(c.reauthorizeInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.performReauthorize(ctx, conn)
+		}
+	}
+}
+
+// heartbeatLoop sends a sequenced PingMsg on heartbeatInterval() for as long
+// as ctx (the current connection's lifetime) is alive, correlating each
+// PongMsg back to its send time in handleHeartbeatPong to compute RTT. A
+// silently half-open connection (NAT rebind, load-balancer idle timeout, a
+// sleeping laptop) never gets a Read error until the next server-initiated
+// message, so this is the only thing that notices it's gone: once
+// heartbeatMissThreshold() pings in a row go unanswered, the peer is treated
+// as dead and the connection is forced closed with StatusAbnormalClosure, so
+// the read loop in connectLoop exits and the normal reconnect/backoff path
+// takes over.
+func (c *Client) heartbeatLoop(ctx context.Context, conn *websocket.Conn) {
+	c.mu.Lock()
+	c.pendingPings = make(map[uint64]time.Time)
+	c.mu.Unlock()
+
+	ticker := time.NewTicker(c.heartbeatInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			c.heartbeatSeq++
+			seq := c.heartbeatSeq
+			c.pendingPings[seq] = time.Now()
+			unanswered := len(c.pendingPings)
+			c.mu.Unlock()
+
+			if unanswered > c.heartbeatMissThreshold() {
+				conn.Close(websocket.StatusAbnormalClosure, "heartbeat: peer stopped responding")
+				return
+			}
+
+			c.sendJSON(ctx, conn, &protocol.PingMsg{Type: "ping", Seq: seq})
+		}
+	}
+}
+
+// handleHeartbeatPong correlates a PongMsg back to the heartbeat ping it
+// answers, computes RTT, and clears it (and any older still-pending pings,
+// which a reply to a newer one implies were answered out of order or lost
+// without ever mattering) from the miss count.
+func (c *Client) handleHeartbeatPong(msg *protocol.PongMsg) {
+	c.mu.Lock()
+	sentAt, ok := c.pendingPings[msg.Seq]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	for seq := range c.pendingPings {
+		if seq <= msg.Seq {
+			delete(c.pendingPings, seq)
+		}
+	}
+	c.lastRTT = time.Since(sentAt)
+	c.lastPongAt = time.Now()
+	rtt := c.lastRTT
+	c.mu.Unlock()
+
+	c.emit(TunnelEvent{Type: "heartbeat", Heartbeat: &HeartbeatInfo{RTT: rtt}})
+}
+
+// resumeAckTimeout bounds how long a connection stays in StatusResuming
+// waiting for a ResumeAckMessage before giving up on distinguishing
+// "resuming" from "connected" and just reporting connected, so a lost or
+// unsupported resume-ack doesn't leave the TUI stuck showing "Resuming..."
+// forever.
+const resumeAckTimeout = 10 * time.Second
+
+// fallbackFromResuming emits StatusConnected if ctx is still alive once
+// resumeAckTimeout elapses and the tunnel is still reporting StatusResuming.
+// It's started right after a resume request is sent; if a ResumeAckMessage
+// arrives first, the status has already moved on (to StatusConnected, or
+// somewhere else entirely, e.g. StatusExpired from an error in between) and
+// this is a no-op rather than stomping that newer status back to connected.
+func (c *Client) fallbackFromResuming(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(resumeAckTimeout):
+		c.mu.Lock()
+		stillResuming := c.lastStatus == StatusResuming
+		c.mu.Unlock()
+		if stillResuming {
+			c.emit(TunnelEvent{Type: "status", Status: StatusConnected})
+		}
+	}
+}
+
+// performReauthorize calls /authorize to re-validate the current session. A
+// 401/403 response, any transport error, or a changed session fingerprint
+// forces the tunnel closed so the normal reconnect/backoff loop takes over.
+func (c *Client) performReauthorize(ctx context.Context, conn *websocket.Conn) {
+	c.emit(TunnelEvent{Type: "status", Status: StatusReauthorizing})
+
+	c.mu.Lock()
+	sessionID := c.sessionID
+	expectedFingerprint := c.sessionFingerprint
+	c.mu.Unlock()
+
+	reqBody, _ := json.Marshal(protocol.AuthorizeRequest{
+		SessionID: sessionID,
+		Subdomain: c.opts.Subdomain,
+	})
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.authorizeURL, bytes.NewReader(reqBody))
+	if err != nil {
+		c.forceReconnect(conn, fmt.Sprintf("failed to build re-authorize request: %s", err.Error()))
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		c.forceReconnect(conn, fmt.Sprintf("re-authorize request failed: %s", err.Error()))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		c.forceReconnect(conn, fmt.Sprintf("re-authorize rejected with status %d: credentials were revoked", resp.StatusCode))
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.forceReconnect(conn, fmt.Sprintf("re-authorize failed with status %d", resp.StatusCode))
+		return
+	}
+
+	var authResp protocol.AuthorizeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		c.forceReconnect(conn, fmt.Sprintf("failed to parse re-authorize response: %s", err.Error()))
+		return
+	}
+
+	if expectedFingerprint != "" && authResp.SessionFingerprint != expectedFingerprint {
+		c.forceReconnect(conn, "session fingerprint changed: routing or credentials were revoked")
+		return
+	}
+
+	c.mu.Lock()
+	c.sessionFingerprint = authResp.SessionFingerprint
+	c.mu.Unlock()
+
+	c.emit(TunnelEvent{Type: "status", Status: StatusConnected})
+}
+
+// manageCertificate runs c.acmeManager's issue/renew loop for the life of
+// ctx (one connection's lifetime). It's started from each auth-ack that
+// authenticates this Client; acmeStarted only guards against the same
+// connection's auth-ack somehow firing twice, not against a reconnect, since
+// ctx is cancelled when that connection ends and the loop below returns,
+// clearing acmeStarted so the next connection's auth-ack starts a fresh one.
+// The actual renew-at-2/3-lifetime decision lives in
+// acme.Manager.EnsureCertificate; this loop just has to call it again before
+// the certificate it last returned expires. It holds off calling
+// EnsureCertificate at all until negotiatedChallengeHost confirms the server
+// is routing our custom domain, so it doesn't race the server's own setup.
+func (c *Client) manageCertificate(ctx context.Context) {
+	c.mu.Lock()
+	if c.acmeStarted {
+		c.mu.Unlock()
+		return
+	}
+	c.acmeStarted = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.acmeStarted = false
+		c.mu.Unlock()
+	}()
+
+	for {
+		c.mu.Lock()
+		ready := c.negotiatedChallengeHost == c.opts.CustomDomain
+		c.mu.Unlock()
+		if !ready {
+			// The server hasn't finished routing public traffic to our custom
+			// domain yet, so an http-01 validation request from the CA right
+			// now would hit the wrong host. Wait for a later auth-ack (e.g.
+			// after a reconnect) to report it before racing ahead.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(acmeChallengeHostPollInterval):
+			}
+			continue
+		}
+
+		result, err := c.acmeManager.EnsureCertificate(ctx)
+		recheck := acmeCheckInterval
+		if err != nil {
+			c.emit(TunnelEvent{Type: "error", Error: fmt.Errorf("acme: %w", err)})
+		} else {
+			if result.Issued {
+				eventType := "cert-issued"
+				if result.Renewed {
+					eventType = "cert-renewed"
+				}
+				c.emit(TunnelEvent{
+					Type: eventType,
+					Cert: &CertInfo{Domain: c.acmeManager.Domain, NotAfter: result.NotAfter},
+				})
+			}
+			if until := time.Until(result.NotAfter); until > 0 && until < recheck {
+				recheck = until
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(recheck):
+		}
+	}
+}
+
+// acmeCheckInterval bounds how long manageCertificate ever sleeps between
+// EnsureCertificate calls, so a missed renewal window (e.g. the process was
+// asleep) is caught within a day rather than only at the next deploy.
+const acmeCheckInterval = 24 * time.Hour
+
+// acmeChallengeHostPollInterval bounds how often manageCertificate rechecks
+// whether the server has caught up to routing our custom domain.
+const acmeChallengeHostPollInterval = 2 * time.Second
+
+// forceReconnect tears down the current connection (and any active relays)
+// in response to a failed re-authorization, surfacing reason so operators
+// can distinguish token revocation from a network flap. The closed
+// connection causes connectLoop's read loop to exit and fall into the
+// existing reconnect/backoff path.
+func (c *Client) forceReconnect(conn *websocket.Conn, reason string) {
+	c.emit(TunnelEvent{Type: "error", Error: fmt.Errorf("re-authorization failed, reconnecting: %s", reason)})
+
+	if c.tcpRelay != nil {
+		c.tcpRelay.closeAll()
+	}
+	if c.wsRelay != nil {
+		c.wsRelay.closeAll()
+	}
+
+	conn.Close(websocket.StatusNormalClosure, "re-authorization failed")
+}
+
 func (c *Client) scheduleReconnect() {
 	c.mu.Lock()
 	if c.disconnectedIntionally {
@@ -460,6 +1630,10 @@ func (c *Client) scheduleReconnect() {
 	c.emit(TunnelEvent{Type: "status", Status: StatusReconnecting})
 
 	delay := CalculateBackoff(attempt)
+	if c.opts.Metrics != nil {
+		c.opts.Metrics.SetReconnectBackoff(delay.Seconds())
+		c.opts.Metrics.IncReconnects(c.opts.Subdomain)
+	}
 	time.AfterFunc(delay, func() {
 		c.mu.Lock()
 		intentional := c.disconnectedIntionally
@@ -470,18 +1644,6 @@ func (c *Client) scheduleReconnect() {
 	})
 }
 
-func concatChunks(chunks [][]byte) []byte {
-	total := 0
-	for _, c := range chunks {
-		total += len(c)
-	}
-	result := make([]byte, 0, total)
-	for _, c := range chunks {
-		result = append(result, c...)
-	}
-	return result
-}
-
 func caseInsensitiveGet(headers map[string]string, name string) (string, bool) {
 	target := name
 	for key, value := range headers {
@@ -507,3 +1669,19 @@ func caseInsensitiveGet(headers map[string]string, name string) (string, bool) {
 	}
 	return "", false
 }
+
+// bodyEligibleForCompress reports whether a body declaring these headers is
+// worth gzip-compressing over the wire. An unknown (streamed/chunked)
+// length is treated as eligible, since minSize only exists to skip the
+// framing overhead on bodies already known to be small.
+func bodyEligibleForCompress(headers map[string]string, minSize int) bool {
+	cl, ok := caseInsensitiveGet(headers, "content-length")
+	if !ok {
+		return true
+	}
+	size, err := strconv.Atoi(cl)
+	if err != nil {
+		return true
+	}
+	return size >= minSize
+}
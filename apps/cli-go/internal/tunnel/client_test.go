@@ -1,11 +1,16 @@
 package tunnel
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -80,13 +85,11 @@ func TestClient_AuthFlow(t *testing.T) {
 			TTL:       3600,
 			Host:      "localhost",
 		},
-		Events:              make(chan TunnelEvent, 100),
-		wsURL:               wsURL + protocol.TunnelConnectPath,
-		maxBodySizeBytes:    protocol.DefaultMaxBodySizeBytes,
-		requestBodyChunks:   make(map[string][][]byte),
-		requestBodySizes:    make(map[string]int),
-		oversizedRequestIDs: make(map[string]struct{}),
-		pendingRequestMeta:  make(map[string]*protocol.HttpRequestMessage),
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
 	}
 
 	go client.connectLoop()
@@ -124,6 +127,84 @@ done:
 	assert.Equal(t, 5*1024*1024, gotAuth.MaxBodySizeBytes)
 }
 
+func TestClient_ReauthorizeForcesReconnectOnFingerprintChange(t *testing.T) {
+	authorizeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := protocol.AuthorizeResponse{
+			TTL:                3600,
+			URL:                "https://test-sub.xpose.dev",
+			SessionFingerprint: "fp-2",
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Errorf("failed to write authorize response: %v", err)
+		}
+	}))
+	defer authorizeServer.Close()
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:               "auth-ack",
+			Subdomain:          "test-sub",
+			URL:                "https://test-sub.xpose.dev",
+			TTL:                3600,
+			SessionID:          "session-1",
+			MaxBodySizeBytes:   5 * 1024 * 1024,
+			SessionFingerprint: "fp-1",
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		// Keep reading until the client forces this connection closed.
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:           "test-sub",
+			Port:                3000,
+			TTL:                 3600,
+			Host:                "localhost",
+			ReauthorizeInterval: 20 * time.Millisecond,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		authorizeURL:     authorizeServer.URL,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	var sawReauthorizing, sawFingerprintError bool
+	timeout := time.After(3 * time.Second)
+	for !sawReauthorizing || !sawFingerprintError {
+		select {
+		case ev := <-client.Events:
+			if ev.Status == StatusReauthorizing {
+				sawReauthorizing = true
+			}
+			if ev.Type == "error" && ev.Error != nil && strings.Contains(ev.Error.Error(), "fingerprint") {
+				sawFingerprintError = true
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for forced reconnect due to fingerprint change")
+		}
+	}
+}
+
 func TestClient_ProxiesHTTPRequest(t *testing.T) {
 	// Local server that the tunnel will proxy to
 	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -212,13 +293,11 @@ func TestClient_ProxiesHTTPRequest(t *testing.T) {
 			TTL:       3600,
 			Host:      localHost,
 		},
-		Events:              make(chan TunnelEvent, 100),
-		wsURL:               wsURL + protocol.TunnelConnectPath,
-		maxBodySizeBytes:    protocol.DefaultMaxBodySizeBytes,
-		requestBodyChunks:   make(map[string][][]byte),
-		requestBodySizes:    make(map[string]int),
-		oversizedRequestIDs: make(map[string]struct{}),
-		pendingRequestMeta:  make(map[string]*protocol.HttpRequestMessage),
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
 	}
 
 	go client.connectLoop()
@@ -240,6 +319,292 @@ func TestClient_ProxiesHTTPRequest(t *testing.T) {
 	}
 }
 
+func TestClient_CompressesResponseBodyWhenServerNegotiatesGzip(t *testing.T) {
+	plainBody := strings.Repeat("compress-me ", 200) // well over 1KB and highly compressible
+
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		if _, err := w.Write([]byte(plainBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	var wireChunks [][]byte
+	serverDone := make(chan struct{})
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		defer close(serverDone)
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var auth protocol.AuthMessage
+		if err := json.Unmarshal(data, &auth); err != nil {
+			t.Errorf("failed to unmarshal auth message: %v", err)
+			return
+		}
+		assert.Contains(t, auth.AcceptedEncodings, "gzip")
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        auth.Subdomain,
+			URL:              "https://test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-1",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			BodyEncoding:     "gzip",
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			t.Errorf("failed to write auth ack: %v", err)
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-gzipresp",
+			Method:  "GET",
+			Path:    "/big",
+			Headers: map[string]string{},
+			HasBody: false,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			t.Errorf("failed to write request message: %v", err)
+			return
+		}
+
+		for i := 0; i < 20; i++ {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if msgType == websocket.MessageBinary {
+				_, body, err := protocol.DecodeBinaryFrame(respData)
+				if err != nil {
+					t.Errorf("failed to decode binary frame: %v", err)
+					return
+				}
+				wireChunks = append(wireChunks, append([]byte(nil), body...))
+				continue
+			}
+
+			var env protocol.Envelope
+			if err := json.Unmarshal(respData, &env); err != nil {
+				t.Errorf("failed to unmarshal envelope: %v", err)
+				return
+			}
+			if env.Type == "http-body-chunk" {
+				var chunk protocol.HttpBodyChunkMessage
+				if err := json.Unmarshal(respData, &chunk); err != nil {
+					t.Errorf("failed to unmarshal http-body-chunk: %v", err)
+					return
+				}
+				assert.Equal(t, "gzip", chunk.Encoding)
+			}
+			if env.Type == "http-response-end" {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "gzip-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	var sawTraffic bool
+	timeout := time.After(3 * time.Second)
+	for !sawTraffic {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				assert.Equal(t, 200, ev.Traffic.Status)
+				sawTraffic = true
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+
+	select {
+	case <-serverDone:
+	case <-timeout:
+		t.Fatal("timed out waiting for mock server to finish reading the response")
+	}
+
+	var wire bytes.Buffer
+	for _, c := range wireChunks {
+		wire.Write(c)
+	}
+	require.NotZero(t, wire.Len())
+	assert.Less(t, wire.Len(), len(plainBody), "wire bytes should be smaller than the plain body")
+
+	gr, err := gzip.NewReader(&wire)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, plainBody, string(decoded))
+}
+
+func TestClient_SendsAndRotatesReconnectToken(t *testing.T) {
+	var gotToken string
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var auth protocol.AuthMessage
+		if err := json.Unmarshal(data, &auth); err != nil {
+			t.Errorf("failed to unmarshal auth message: %v", err)
+			return
+		}
+		gotToken = auth.ReconnectToken
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-sub",
+			URL:              "https://test-sub.xpose.dev",
+			TTL:              3600,
+			SessionID:        "session-123",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			ReconnectToken:   "rotated-token",
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	var callbackToken string
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:      "test-sub",
+			Port:           3000,
+			TTL:            3600,
+			Host:           "localhost",
+			ReconnectToken: "initial-token",
+			OnReconnectToken: func(token string) {
+				callbackToken = token
+			},
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		reconnectToken:   "initial-token",
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Type == "authenticated" {
+				goto done
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for authenticated event")
+		}
+	}
+done:
+
+	assert.Equal(t, "initial-token", gotToken, "client should present its stored reconnect token on connect")
+	assert.Equal(t, "rotated-token", callbackToken, "client should notify callers when the server rotates the token")
+
+	client.mu.Lock()
+	assert.Equal(t, "rotated-token", client.reconnectToken)
+	client.mu.Unlock()
+}
+
+func TestClient_SessionRejectionClearsReconnectTokenAndExpires(t *testing.T) {
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		errMsg := protocol.ErrorMessage{
+			Type:    "error",
+			Message: "Invalid or expired reconnect token",
+			Status:  401,
+		}
+		errData, _ := json.Marshal(errMsg)
+		if err := conn.Write(ctx, websocket.MessageText, errData); err != nil {
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:      "test-sub",
+			Port:           3000,
+			TTL:            3600,
+			Host:           "localhost",
+			ReconnectToken: "stale-token",
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		reconnectToken:   "stale-token",
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	gotExpired := false
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Type == "expired" || ev.Status == StatusExpired {
+				gotExpired = true
+				goto done
+			}
+		case <-timeout:
+			goto done
+		}
+	}
+done:
+	assert.True(t, gotExpired, "a session-level 401 should be treated as expiry")
+
+	client.mu.Lock()
+	assert.Empty(t, client.reconnectToken, "a rejected reconnect token must not be reused")
+	client.mu.Unlock()
+}
+
 func TestClient_TTLExpired(t *testing.T) {
 	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
 		// Read auth
@@ -286,13 +651,11 @@ func TestClient_TTLExpired(t *testing.T) {
 			TTL:       1,
 			Host:      "localhost",
 		},
-		Events:              make(chan TunnelEvent, 100),
-		wsURL:               wsURL + protocol.TunnelConnectPath,
-		maxBodySizeBytes:    protocol.DefaultMaxBodySizeBytes,
-		requestBodyChunks:   make(map[string][][]byte),
-		requestBodySizes:    make(map[string]int),
-		oversizedRequestIDs: make(map[string]struct{}),
-		pendingRequestMeta:  make(map[string]*protocol.HttpRequestMessage),
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
 	}
 
 	go client.connectLoop()
@@ -317,3 +680,1576 @@ func TestClient_TTLExpired(t *testing.T) {
 done:
 	assert.True(t, gotExpired, "expected expired event")
 }
+
+func TestClient_StreamsLargeResponseInMultipleChunks(t *testing.T) {
+	// Larger than the 64KB chunk buffer so the body must be streamed in
+	// more than one http-body-chunk/binary-frame pair.
+	bigBody := strings.Repeat("y", 200*1024)
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		if _, err := w.Write([]byte(bigBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "stream-test",
+			URL:              "https://stream-test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-1",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-stream1234",
+			Method:  "GET",
+			Path:    "/big",
+			Headers: map[string]string{},
+			HasBody: false,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			return
+		}
+
+		var chunkCount int
+		var received []byte
+		for {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			switch msgType {
+			case websocket.MessageText:
+				var env protocol.Envelope
+				if err := json.Unmarshal(respData, &env); err != nil {
+					return
+				}
+				if env.Type == "http-body-chunk" {
+					chunkCount++
+				}
+				if env.Type == "http-response-end" {
+					assert.Greater(t, chunkCount, 1, "expected more than one chunk for a 200KB body")
+					assert.Equal(t, bigBody, string(received))
+					return
+				}
+			case websocket.MessageBinary:
+				_, chunk, err := protocol.DecodeBinaryFrame(respData)
+				if err != nil {
+					return
+				}
+				received = append(received, chunk...)
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "stream-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				assert.Equal(t, 200, ev.Traffic.Status)
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+}
+
+func TestClient_V2FramingBlocksUntilWindowReplenished(t *testing.T) {
+	chunkSize := 64 * 1024
+	bigBody := strings.Repeat("z", chunkSize*5)
+
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		if _, err := w.Write([]byte(bigBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "v2-test",
+			URL:              "https://v2-test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-v2",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			ProtocolVersion:  2,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-v2frame1",
+			Method:  "GET",
+			Path:    "/big",
+			Headers: map[string]string{},
+			HasBody: false,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			return
+		}
+
+		var frameCount int
+		var sentWindowUpdate bool
+		for {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			switch msgType {
+			case websocket.MessageText:
+				var env protocol.Envelope
+				if err := json.Unmarshal(respData, &env); err != nil {
+					return
+				}
+				if env.Type == "http-response-end" {
+					// Each 64KB chunk is split into two DefaultFrameMTU (32KB)
+					// sub-frames by the Framer, so 5 chunks come across as 10
+					// frames rather than 5.
+					assert.Equal(t, 10, frameCount, "expected exactly 10 frames for a 320KB body in 64KB chunks split at a 32KB MTU")
+					return
+				}
+			case websocket.MessageBinary:
+				frames, err := protocol.DecodeFrames(respData)
+				if err != nil {
+					t.Errorf("failed to decode v2 frames: %v", err)
+					return
+				}
+				frameCount += len(frames)
+
+				if frameCount >= 8 && !sentWindowUpdate {
+					sentWindowUpdate = true
+					// The initial window is exactly 4 chunks (8 sub-frames) of
+					// credit, so the client should now be blocked waiting for
+					// more before it sends the 5th chunk's frames.
+					time.Sleep(100 * time.Millisecond)
+					assert.Equal(t, 8, frameCount, "client should not send the 5th chunk's frames before window replenishment")
+
+					update := protocol.EncodeFrame(protocol.NewWindowUpdateFrame("req-v2frame1", uint32(chunkSize)))
+					if err := conn.Write(ctx, websocket.MessageBinary, update); err != nil {
+						return
+					}
+				}
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "v2-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+		responseWindows:  make(map[string]*protocol.Window),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				assert.Equal(t, 200, ev.Traffic.Status)
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+}
+
+func TestClient_RequestBodyStreamGrantsFlowControlAndForwardsFullBody(t *testing.T) {
+	chunkSize := 32 * 1024
+	chunkCount := 4
+	wantBody := strings.Repeat("r", chunkSize*chunkCount)
+
+	var gotBody []byte
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		gotBody, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+		}
+		w.WriteHeader(200)
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "reqbody-test",
+			URL:              "https://reqbody-test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-reqbody",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-bodyflow",
+			Method:  "POST",
+			Path:    "/upload",
+			Headers: map[string]string{},
+			HasBody: true,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			return
+		}
+
+		// The client should grant an initial receive window before any chunk
+		// is sent, sized to protocol.DefaultRequestWindowSizeBytes.
+		_, flowData, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var flow protocol.HttpFlowControlMessage
+		if err := json.Unmarshal(flowData, &flow); err != nil {
+			t.Errorf("failed to unmarshal flow-control message: %v", err)
+			return
+		}
+		assert.Equal(t, "req-bodyflow", flow.ID)
+		assert.Equal(t, protocol.DefaultRequestWindowSizeBytes, flow.WindowIncrement)
+
+		for i := 0; i < chunkCount; i++ {
+			chunk := []byte(wantBody[i*chunkSize : (i+1)*chunkSize])
+			frame := protocol.EncodeBinaryFrame("req-bodyflow", chunk)
+			if err := conn.Write(ctx, websocket.MessageBinary, frame); err != nil {
+				return
+			}
+		}
+
+		endMsg := protocol.HttpRequestEndMessage{Type: "http-request-end", ID: "req-bodyflow"}
+		endData, _ := json.Marshal(endMsg)
+		if err := conn.Write(ctx, websocket.MessageText, endData); err != nil {
+			return
+		}
+
+		for {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if msgType != websocket.MessageText {
+				continue
+			}
+			var env protocol.Envelope
+			if err := json.Unmarshal(respData, &env); err != nil {
+				return
+			}
+			if env.Type == "http-response-end" {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "reqbody-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				assert.Equal(t, 200, ev.Traffic.Status)
+				assert.Equal(t, wantBody, string(gotBody))
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+}
+
+func TestClient_DecompressesGzipEncodedRequestBody(t *testing.T) {
+	wantBody := strings.Repeat("decompress-me ", 200)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write([]byte(wantBody)); err != nil {
+		t.Fatalf("failed to gzip request body fixture: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	var gotBody []byte
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		gotBody, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+		}
+		w.WriteHeader(200)
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "reqgzip-test",
+			URL:              "https://reqgzip-test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-reqgzip",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			BodyEncoding:     "gzip",
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "reqgzipbody1",
+			Method:  "POST",
+			Path:    "/upload",
+			Headers: map[string]string{},
+			HasBody: true,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			return
+		}
+
+		if _, _, err := conn.Read(ctx); err != nil { // flow-control grant
+			return
+		}
+
+		chunkMsg := protocol.HttpBodyChunkMessage{
+			Type:     "http-body-chunk",
+			ID:       "reqgzipbody1",
+			Done:     false,
+			Encoding: "gzip",
+		}
+		chunkData, _ := json.Marshal(chunkMsg)
+		if err := conn.Write(ctx, websocket.MessageText, chunkData); err != nil {
+			return
+		}
+		frame := protocol.EncodeBinaryFrame("reqgzipbody1", compressed.Bytes())
+		if err := conn.Write(ctx, websocket.MessageBinary, frame); err != nil {
+			return
+		}
+
+		endMsg := protocol.HttpRequestEndMessage{Type: "http-request-end", ID: "reqgzipbody1"}
+		endData, _ := json.Marshal(endMsg)
+		if err := conn.Write(ctx, websocket.MessageText, endData); err != nil {
+			return
+		}
+
+		for {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if msgType != websocket.MessageText {
+				continue
+			}
+			var env protocol.Envelope
+			if err := json.Unmarshal(respData, &env); err != nil {
+				return
+			}
+			if env.Type == "http-response-end" {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "reqgzip-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				assert.Equal(t, 200, ev.Traffic.Status)
+				assert.Equal(t, wantBody, string(gotBody))
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+}
+
+func TestClient_HttpCancelAbortsInFlightRequestBody(t *testing.T) {
+	serverCanceled := make(chan struct{})
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// net/http only watches for the client closing the connection once the
+		// handler starts consuming the body, so read it rather than waiting on
+		// r.Context().Done() directly; the abort surfaces here as a read error.
+		_, err := io.Copy(io.Discard, r.Body)
+		if err == nil {
+			return
+		}
+		close(serverCanceled)
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "cancel-test",
+			URL:              "https://cancel-test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-cancel",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-cancel12",
+			Method:  "POST",
+			Path:    "/upload",
+			Headers: map[string]string{},
+			HasBody: true,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			return
+		}
+
+		// Wait for the initial flow-control grant before canceling, so the
+		// request is actually in flight (ProxyRequest already dialed out).
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		cancelMsg := protocol.HttpCancelMessage{
+			Type:   "http-cancel",
+			ID:     "req-cancel12",
+			Reason: "downstream client disconnected",
+		}
+		cancelData, _ := json.Marshal(cancelMsg)
+		if err := conn.Write(ctx, websocket.MessageText, cancelData); err != nil {
+			return
+		}
+
+		// Drain until the connection closes so connectLoop can run to completion.
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "cancel-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	select {
+	case <-serverCanceled:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the local server to observe the cancellation")
+	}
+}
+
+func TestClient_SendsResumeMessageWhenAuthAckConfirmsSameSession(t *testing.T) {
+	var gotResume *protocol.ResumeMessage
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-sub",
+			URL:              "https://test-sub.xpose.dev",
+			TTL:              3600,
+			SessionID:        "session-123",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			Capabilities:     []string{protocol.CapabilitySessionResume},
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var resume protocol.ResumeMessage
+		if err := json.Unmarshal(data, &resume); err != nil {
+			t.Errorf("failed to unmarshal resume message: %v", err)
+			return
+		}
+		gotResume = &resume
+
+		resumeAck := protocol.ResumeAckMessage{Type: "resume-ack", SessionID: "session-123"}
+		resumeAckData, _ := json.Marshal(resumeAck)
+		conn.Write(ctx, websocket.MessageText, resumeAckData)
+
+		time.Sleep(100 * time.Millisecond)
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "test-sub",
+			Port:      3000,
+			TTL:       3600,
+			Host:      "localhost",
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		sessionID:        "session-123", // simulates a session already established before this reconnect
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	var gotResumedEvent *ResumedInfo
+	var sawResuming bool
+	var statusAtResumedEvent TunnelStatus
+	timeout := time.After(2 * time.Second)
+	for gotResumedEvent == nil {
+		select {
+		case ev := <-client.Events:
+			switch ev.Type {
+			case "status":
+				if ev.Status == StatusResuming {
+					sawResuming = true
+				}
+				statusAtResumedEvent = ev.Status
+			case "resumed":
+				gotResumedEvent = ev.Resumed
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for resumed event")
+		}
+	}
+
+	require.NotNil(t, gotResume)
+	assert.Equal(t, "session-123", gotResume.SessionID)
+	require.NotNil(t, gotResumedEvent)
+	assert.Equal(t, "session-123", gotResumedEvent.SessionID)
+	assert.True(t, sawResuming, "expected a StatusResuming status event while waiting for the resume-ack")
+	assert.Equal(t, StatusConnected, statusAtResumedEvent, "status should be connected by the time resumed fires")
+}
+
+func TestClient_NoResumeMessageOnFreshSession(t *testing.T) {
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-sub",
+			URL:              "https://test-sub.xpose.dev",
+			TTL:              3600,
+			SessionID:        "new-session",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		_, data, err := conn.Read(readCtx)
+		if err != nil {
+			return // no further message within the window, as expected
+		}
+		var env protocol.Envelope
+		if err := json.Unmarshal(data, &env); err == nil && env.Type == "resume" {
+			t.Error("client should not send a resume message when the server issued a fresh session")
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "test-sub",
+			Port:      3000,
+			TTL:       3600,
+			Host:      "localhost",
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		sessionID:        "old-session",
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Type == "authenticated" {
+				goto done
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for authenticated event")
+		}
+	}
+done:
+	time.Sleep(300 * time.Millisecond) // give the server handler time to observe no resume message
+}
+
+func TestClient_NoResumeMessageWhenServerLacksSessionResumeCapability(t *testing.T) {
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-sub",
+			URL:              "https://test-sub.xpose.dev",
+			TTL:              3600,
+			SessionID:        "session-123",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			// Capabilities intentionally omits CapabilitySessionResume.
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		_, data, err := conn.Read(readCtx)
+		if err != nil {
+			return // no further message within the window, as expected
+		}
+		var env protocol.Envelope
+		if err := json.Unmarshal(data, &env); err == nil && env.Type == "resume" {
+			t.Error("client should not send a resume message when the server didn't negotiate session-resume")
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "test-sub",
+			Port:      3000,
+			TTL:       3600,
+			Host:      "localhost",
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		sessionID:        "session-123",
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Type == "authenticated" {
+				goto done
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for authenticated event")
+		}
+	}
+done:
+	time.Sleep(300 * time.Millisecond) // give the server handler time to observe no resume message
+}
+
+func TestClient_ResumeAckReplaysBufferedResponseForRequestedID(t *testing.T) {
+	replayedCh := make(chan []byte, 1)
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-sub",
+			URL:              "https://test-sub.xpose.dev",
+			TTL:              3600,
+			SessionID:        "session-123",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+			Capabilities:     []string{protocol.CapabilitySessionResume},
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		if _, _, err := conn.Read(ctx); err != nil { // resume
+			return
+		}
+
+		resumeAck := protocol.ResumeAckMessage{Type: "resume-ack", SessionID: "session-123", ResumedRequestIDs: []string{"req-1"}}
+		resumeAckData, _ := json.Marshal(resumeAck)
+		if err := conn.Write(ctx, websocket.MessageText, resumeAckData); err != nil {
+			return
+		}
+
+		_, data, err := conn.Read(ctx) // the replayed response
+		if err != nil {
+			return
+		}
+		replayedCh <- data
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	buf := newResumeBuffer(0, 0)
+	buf.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte(`{"type":"http-response-end","id":"req-1"}`)})
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "test-sub",
+			Port:      3000,
+			TTL:       3600,
+			Host:      "localhost",
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		sessionID:        "session-123",
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+		resumeBuf:        buf,
+	}
+
+	go client.connectLoop()
+
+	var replayed []byte
+	timeout := time.After(2 * time.Second)
+	for replayed == nil {
+		select {
+		case data := <-replayedCh:
+			replayed = data
+		case <-client.Events:
+		case <-timeout:
+			t.Fatal("timed out waiting for the replayed response")
+		}
+	}
+
+	assert.JSONEq(t, `{"type":"http-response-end","id":"req-1"}`, string(replayed))
+}
+
+func TestClient_PublishesRouteConfigWhenMultipleRoutesConfigured(t *testing.T) {
+	routeConfigCh := make(chan protocol.RouteConfigMessage, 1)
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil { // auth
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-sub",
+			URL:              "https://test-sub.xpose.dev",
+			TTL:              3600,
+			SessionID:        "session-123",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		_, data, err := conn.Read(ctx) // route-config
+		if err != nil {
+			return
+		}
+		var msg protocol.RouteConfigMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Errorf("failed to unmarshal route-config message: %v", err)
+			return
+		}
+		routeConfigCh <- msg
+
+		time.Sleep(200 * time.Millisecond)
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "test-sub",
+			TTL:       3600,
+			Routes: []Route{
+				{PathPrefix: "/api", Host: "localhost", Port: 8080},
+				{PathPrefix: "", Host: "localhost", Port: 3000},
+			},
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	var routeConfig protocol.RouteConfigMessage
+	timeout := time.After(2 * time.Second)
+	select {
+	case routeConfig = <-routeConfigCh:
+	case <-timeout:
+		t.Fatal("timed out waiting for route-config message")
+	}
+
+	require.Equal(t, "route-config", routeConfig.Type)
+	require.Len(t, routeConfig.Routes, 2)
+	assert.Equal(t, "/api", routeConfig.Routes[0].PathPrefix)
+	assert.Equal(t, 8080, routeConfig.Routes[0].Port)
+	assert.Equal(t, "", routeConfig.Routes[1].PathPrefix)
+	assert.Equal(t, 3000, routeConfig.Routes[1].Port)
+}
+
+func TestClient_HeartbeatComputesRTTAndExposesStats(t *testing.T) {
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-hb",
+			URL:              "https://test-hb.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-hb",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		// Answer the first ping it receives and then idle, so the test only
+		// needs one round trip to observe RTT.
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var ping protocol.PingMsg
+			if err := json.Unmarshal(data, &ping); err != nil || ping.Type != "ping" {
+				continue
+			}
+			pong := protocol.PongMsg{Type: "pong", Seq: ping.Seq}
+			pongData, _ := json.Marshal(pong)
+			if err := conn.Write(ctx, websocket.MessageText, pongData); err != nil {
+				return
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:         "test-hb",
+			Port:              3000,
+			TTL:               3600,
+			Host:              "localhost",
+			HeartbeatInterval: 20 * time.Millisecond,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Type == "heartbeat" {
+				require.NotNil(t, ev.Heartbeat)
+				assert.GreaterOrEqual(t, ev.Heartbeat.RTT, time.Duration(0))
+				stats := client.Stats()
+				assert.Equal(t, ev.Heartbeat.RTT, stats.RTT)
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for heartbeat event")
+		}
+	}
+}
+
+func TestClient_HeartbeatForcesReconnectAfterMissedPongs(t *testing.T) {
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-hb-miss",
+			URL:              "https://test-hb-miss.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-hb-miss",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		// Never answer any ping, so the client's heartbeat gives up and
+		// forces the connection closed on its own.
+		for {
+			if _, _, err := conn.Read(ctx); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:              "test-hb-miss",
+			Port:                   3000,
+			TTL:                    3600,
+			Host:                   "localhost",
+			HeartbeatInterval:      10 * time.Millisecond,
+			HeartbeatMissThreshold: 2,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	gotReconnecting := false
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Status == StatusReconnecting {
+				gotReconnecting = true
+				goto done
+			}
+		case <-timeout:
+			goto done
+		}
+	}
+done:
+	assert.True(t, gotReconnecting, "expected the dead connection to be torn down and reconnect scheduled")
+}
+
+func TestClient_HeartbeatDoesNotPoisonNextConnectionAfterForcedReconnect(t *testing.T) {
+	var connCount int32
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		n := atomic.AddInt32(&connCount, 1)
+
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "test-hb-recover",
+			URL:              "https://test-hb-recover.xpose.dev",
+			TTL:              3600,
+			SessionID:        fmt.Sprintf("sess-%d", n),
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		if n == 1 {
+			// Never answer a ping, so the client's own heartbeat gives up on
+			// this first connection and forces a reconnect.
+			for {
+				if _, _, err := conn.Read(ctx); err != nil {
+					return
+				}
+			}
+		}
+
+		// The second connection behaves: answer every ping so the reconnected
+		// tunnel should stay up rather than immediately being killed again by
+		// stale state left over from the first connection's heartbeat.
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var ping protocol.PingMsg
+			if err := json.Unmarshal(data, &ping); err != nil || ping.Type != "ping" {
+				continue
+			}
+			pong := protocol.PongMsg{Type: "pong", Seq: ping.Seq}
+			pongData, _ := json.Marshal(pong)
+			if err := conn.Write(ctx, websocket.MessageText, pongData); err != nil {
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:              "test-hb-recover",
+			Port:                   3000,
+			TTL:                    3600,
+			Host:                   "localhost",
+			HeartbeatInterval:      15 * time.Millisecond,
+			HeartbeatMissThreshold: 1,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	sawReconnecting := false
+	sawSecondAuth := false
+	authCount := 0
+	timeout := time.After(3 * time.Second)
+	for !sawSecondAuth {
+		select {
+		case ev := <-client.Events:
+			if ev.Status == StatusReconnecting {
+				sawReconnecting = true
+			}
+			if ev.Type == "authenticated" {
+				authCount++
+				if sawReconnecting && authCount >= 2 {
+					sawSecondAuth = true
+				}
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the client to reconnect and re-authenticate")
+		}
+	}
+
+	// The reconnected tunnel should survive several more heartbeat intervals
+	// without being torn down again by leftover state from the first
+	// connection's missed pings.
+	settleTimeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case ev := <-client.Events:
+			assert.NotEqual(t, StatusReconnecting, ev.Status, "reconnected tunnel was torn down again by stale heartbeat state")
+		case <-settleTimeout:
+			return
+		}
+	}
+}
+
+func TestClient_RecordBodiesPopulatesHistoryAndExportHAR(t *testing.T) {
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+		w.Write([]byte("ok"))
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var auth protocol.AuthMessage
+		if err := json.Unmarshal(data, &auth); err != nil {
+			t.Errorf("failed to unmarshal auth message: %v", err)
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        auth.Subdomain,
+			URL:              "https://test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-1",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			t.Errorf("failed to write auth ack: %v", err)
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-abc123abc1",
+			Method:  "GET",
+			Path:    "/hello",
+			Headers: map[string]string{},
+			HasBody: false,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			t.Errorf("failed to write request message: %v", err)
+			return
+		}
+
+		for i := 0; i < 10; i++ {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			if msgType == websocket.MessageText {
+				var env protocol.Envelope
+				if err := json.Unmarshal(respData, &env); err != nil {
+					t.Errorf("failed to unmarshal envelope: %v", err)
+					return
+				}
+				if env.Type == "http-response-end" {
+					return
+				}
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:    "history-test",
+			Port:         localPort,
+			TTL:          3600,
+			Host:         localHost,
+			RecordBodies: true,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+		history:          newHistoryBuffer(0),
+	}
+
+	go client.connectLoop()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				goto recorded
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+
+recorded:
+	history := client.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, "/hello", history[0].Path)
+
+	var buf bytes.Buffer
+	require.NoError(t, client.ExportHAR(&buf))
+	assert.Contains(t, buf.String(), "/hello")
+}
+
+// TestClient_StreamsSSEResponseAsItArrives verifies that a text/event-stream
+// response is forwarded frame-by-frame as the local handler flushes it,
+// rather than being buffered until the handler finishes writing. It proves
+// out the literal SSE case the io.Pipe/streamResponseBody streaming design
+// (see TestClient_StreamsLargeResponseInMultipleChunks for the large-body
+// case) is meant to support.
+func TestClient_StreamsSSEResponseAsItArrives(t *testing.T) {
+	firstChunkSent := make(chan struct{})
+	localServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		flusher := w.(http.Flusher)
+
+		fmt.Fprint(w, "data: first\n\n")
+		flusher.Flush()
+		close(firstChunkSent)
+
+		// Hold the connection open without finishing the body, so a test
+		// that only passes by buffering the full response would hang here
+		// instead of observing the first chunk already delivered.
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, "data: second\n\n")
+		flusher.Flush()
+	}))
+	defer localServer.Close()
+
+	localHost, localPort := parseHostPort(localServer.URL)
+
+	firstFrameSeen := make(chan struct{})
+	server := mockTunnelServer(t, func(ctx context.Context, conn *websocket.Conn) {
+		if _, _, err := conn.Read(ctx); err != nil {
+			return
+		}
+
+		ack := protocol.AuthAckMessage{
+			Type:             "auth-ack",
+			Subdomain:        "sse-test",
+			URL:              "https://sse-test.xpose.dev",
+			TTL:              3600,
+			SessionID:        "sess-1",
+			MaxBodySizeBytes: 5 * 1024 * 1024,
+		}
+		ackData, _ := json.Marshal(ack)
+		if err := conn.Write(ctx, websocket.MessageText, ackData); err != nil {
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		reqMsg := protocol.HttpRequestMessage{
+			Type:    "http-request",
+			ID:      "req-sse12345a",
+			Method:  "GET",
+			Path:    "/events",
+			Headers: map[string]string{},
+			HasBody: false,
+		}
+		reqData, _ := json.Marshal(reqMsg)
+		if err := conn.Write(ctx, websocket.MessageText, reqData); err != nil {
+			return
+		}
+
+		var received []byte
+		for {
+			msgType, respData, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			switch msgType {
+			case websocket.MessageText:
+				var env protocol.Envelope
+				if err := json.Unmarshal(respData, &env); err != nil {
+					return
+				}
+				if env.Type == "http-response-end" {
+					assert.Contains(t, string(received), "data: first")
+					assert.Contains(t, string(received), "data: second")
+					return
+				}
+			case websocket.MessageBinary:
+				_, chunk, err := protocol.DecodeBinaryFrame(respData)
+				if err != nil {
+					return
+				}
+				if len(received) == 0 {
+					close(firstFrameSeen)
+				}
+				received = append(received, chunk...)
+			}
+		}
+	})
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "sse-test",
+			Port:      localPort,
+			TTL:       3600,
+			Host:      localHost,
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL + protocol.TunnelConnectPath,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	select {
+	case <-firstChunkSent:
+	case <-time.After(2 * time.Second):
+		t.Fatal("local SSE handler never flushed its first chunk")
+	}
+
+	// The first frame should reach the mock tunnel server well before the
+	// local handler's 200ms delay before its second write elapses, proving
+	// the body isn't buffered until the handler finishes.
+	select {
+	case <-firstFrameSeen:
+	case <-time.After(150 * time.Millisecond):
+		t.Fatal("first SSE chunk wasn't forwarded before the handler's second write")
+	}
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-client.Events:
+			if ev.Traffic != nil {
+				assert.Equal(t, 200, ev.Traffic.Status)
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for traffic event")
+		}
+	}
+}
+
+// TestClient_DialSendsBearerTokenAndRejects401AsAuthError verifies that
+// ClientOptions.BearerToken is sent as an Authorization header on the dial
+// handshake, and that a 401 response from the edge is surfaced as a
+// distinct "auth_error" event instead of a generic dial error (so the TUI
+// can stop retrying rather than backing off forever).
+func TestClient_DialSendsBearerTokenAndRejects401AsAuthError(t *testing.T) {
+	gotAuth := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth <- r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain:   "auth-test",
+			BearerToken: "secret-token",
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	select {
+	case auth := <-gotAuth:
+		assert.Equal(t, "Bearer secret-token", auth)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server never received the dial request")
+	}
+
+	var sawAuthError, sawExpiredStatus, sawExpiredEvent bool
+	timeout := time.After(2 * time.Second)
+	for !sawAuthError || !sawExpiredStatus || !sawExpiredEvent {
+		select {
+		case ev := <-client.Events:
+			switch {
+			case ev.Type == "auth_error":
+				assert.Error(t, ev.Error)
+				sawAuthError = true
+			case ev.Type == "status" && ev.Status == StatusExpired:
+				sawExpiredStatus = true
+			case ev.Type == "expired":
+				sawExpiredEvent = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for the auth_error/expired sequence (auth_error=%v, expired status=%v, expired event=%v)", sawAuthError, sawExpiredStatus, sawExpiredEvent)
+		}
+	}
+}
+
+// TestClient_DialHonorsHeaderFuncAndSubprotocols verifies that HeaderFunc's
+// headers and Subprotocols both reach the dial handshake request.
+func TestClient_DialHonorsHeaderFuncAndSubprotocols(t *testing.T) {
+	gotHeader := make(chan string, 1)
+	gotSubprotocol := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader <- r.Header.Get("X-Custom-Auth")
+		gotSubprotocol <- r.Header.Get("Sec-WebSocket-Protocol")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client := &Client{
+		opts: ClientOptions{
+			Subdomain: "auth-test-2",
+			HeaderFunc: func() http.Header {
+				h := http.Header{}
+				h.Set("X-Custom-Auth", "signed-value")
+				return h
+			},
+			Subprotocols: []string{"xpose.v2"},
+		},
+		Events:           make(chan TunnelEvent, 100),
+		wsURL:            wsURL,
+		maxBodySizeBytes: protocol.DefaultMaxBodySizeBytes,
+		requestStreams:   make(map[string]*requestStream),
+		activeCancels:    make(map[string]context.CancelFunc),
+	}
+
+	go client.connectLoop()
+
+	select {
+	case header := <-gotHeader:
+		assert.Equal(t, "signed-value", header)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server never received the dial request")
+	}
+	assert.Equal(t, "xpose.v2", <-gotSubprotocol)
+}
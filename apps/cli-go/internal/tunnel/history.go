@@ -0,0 +1,47 @@
+package tunnel
+
+import "sync"
+
+// defaultHistoryEntries bounds the history ring buffer when ClientOptions
+// doesn't override it.
+const defaultHistoryEntries = 100
+
+// historyBuffer is a bounded ring buffer of TrafficEntry, recorded only when
+// ClientOptions.RecordBodies is set. Unlike resumeBuffer (which exists to
+// replay responses the server might have missed), this is purely for a
+// caller to inspect what the tunnel has carried, e.g. for Client.ExportHAR.
+// The zero value is not usable; use newHistoryBuffer.
+type historyBuffer struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    []TrafficEntry
+}
+
+// newHistoryBuffer creates a historyBuffer, falling back to
+// defaultHistoryEntries if maxEntries is left at zero.
+func newHistoryBuffer(maxEntries int) *historyBuffer {
+	if maxEntries <= 0 {
+		maxEntries = defaultHistoryEntries
+	}
+	return &historyBuffer{maxEntries: maxEntries}
+}
+
+// record appends entry, evicting the oldest entry if the buffer is full.
+func (b *historyBuffer) record(entry TrafficEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, entry)
+	if len(b.entries) > b.maxEntries {
+		b.entries = b.entries[len(b.entries)-b.maxEntries:]
+	}
+}
+
+// snapshot returns a copy of the currently recorded entries, oldest first.
+func (b *historyBuffer) snapshot() []TrafficEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]TrafficEntry, len(b.entries))
+	copy(out, b.entries)
+	return out
+}
@@ -0,0 +1,40 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryBuffer_SnapshotReflectsRecordedEntries(t *testing.T) {
+	b := newHistoryBuffer(0)
+	b.record(TrafficEntry{Method: "GET", Path: "/one"})
+	b.record(TrafficEntry{Method: "GET", Path: "/two"})
+
+	got := b.snapshot()
+	assert.Len(t, got, 2)
+	assert.Equal(t, "/one", got[0].Path)
+	assert.Equal(t, "/two", got[1].Path)
+}
+
+func TestHistoryBuffer_EvictsOldestEntriesPastEntryCap(t *testing.T) {
+	b := newHistoryBuffer(2)
+	b.record(TrafficEntry{Path: "/one"})
+	b.record(TrafficEntry{Path: "/two"})
+	b.record(TrafficEntry{Path: "/three"})
+
+	got := b.snapshot()
+	assert.Len(t, got, 2)
+	assert.Equal(t, "/two", got[0].Path)
+	assert.Equal(t, "/three", got[1].Path)
+}
+
+func TestHistoryBuffer_SnapshotIsDefensiveCopy(t *testing.T) {
+	b := newHistoryBuffer(0)
+	b.record(TrafficEntry{Path: "/one"})
+
+	got := b.snapshot()
+	got[0].Path = "/mutated"
+
+	assert.Equal(t, "/one", b.snapshot()[0].Path)
+}
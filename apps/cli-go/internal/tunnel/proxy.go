@@ -1,21 +1,35 @@
 package tunnel
 
 import (
-	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
-	"github.com/xpose-dev/xpose/internal/protocol"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/nkootstra/xpose/internal/protocol"
 )
 
 // ProxyResponse holds the result of proxying a request to the local server.
+// Body streams the response so it can be forwarded frame-by-frame (e.g. for
+// SSE, long-polling, or large downloads) instead of being buffered in memory.
+// Callers are responsible for closing it. Decoded reports whether Body has
+// already been transparently decompressed (see ProxyRequest's
+// preserveEncoding parameter).
 type ProxyResponse struct {
 	Status  int
 	Headers map[string]string
-	Body    []byte
+	Body    io.ReadCloser
+	Decoded bool
 }
 
 // skipHeaders are headers that should not be forwarded to the local server.
@@ -25,20 +39,54 @@ var skipHeaders = map[string]bool{
 	"transfer-encoding": true,
 }
 
-// ProxyRequest forwards an HTTP request to the local server and returns the response.
-func ProxyRequest(host string, port int, msg *protocol.HttpRequestMessage, body []byte, maxBodySize int) (*ProxyResponse, error) {
-	localURL := fmt.Sprintf("http://%s:%d%s", host, port, msg.Path)
-
-	var reqBody io.Reader
-	if body != nil {
-		reqBody = bytes.NewReader(body)
+// ProxyRequest forwards an HTTP request to the local server and returns the
+// response with its body left unread. body may be a streaming reader (e.g.
+// fed incrementally as tunnel frames arrive) rather than a fully buffered
+// one; ctx governs the whole round trip, so canceling it aborts an
+// in-flight request body read or response body read alike. maxBodySize is
+// enforced here against a declared Content-Length (on the wire size) and,
+// unless preserveEncoding is set, against the decompressed size of a
+// recognized Content-Encoding (gzip, deflate, br, zstd) as it streams;
+// callers that stream an undeclared-length body are responsible for
+// aborting once the actual byte count exceeds maxBodySize. preserveEncoding
+// skips decompression entirely, forwarding the body (and its
+// Content-Encoding header) byte-for-byte, for callers that need binary
+// passthrough. proxyURL, if non-nil, routes the dial through that proxy
+// instead of consulting HTTP_PROXY/HTTPS_PROXY/NO_PROXY; pass nil to honor
+// the environment (the common case: some dev/CI environments require
+// egress through a proxy to reach the target). The environment lookup
+// never proxies a target of "localhost" or a loopback IP; those are always
+// dialed directly, and only an explicit proxyURL routes them through a
+// proxy. scheme picks
+// http or https for the local dial (anything other than "https" is treated
+// as "http"); insecureSkipVerify, meaningful only for an https scheme, skips
+// certificate verification for local dev servers using self-signed certs.
+func ProxyRequest(ctx context.Context, host string, port int, msg *protocol.HttpRequestMessage, body io.Reader, maxBodySize int, preserveEncoding bool, proxyURL *url.URL, scheme string, insecureSkipVerify bool) (*ProxyResponse, error) {
+	if scheme != "https" {
+		scheme = "http"
 	}
+	localURL := fmt.Sprintf("%s://%s:%d%s", scheme, host, port, msg.Path)
 
-	req, err := http.NewRequest(msg.Method, localURL, reqBody)
+	req, err := http.NewRequestWithContext(ctx, msg.Method, localURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// http.NewRequestWithContext only infers ContentLength for a handful of
+	// well-known in-memory reader types; for everything else (notably the
+	// io.PipeReader a streamed request body arrives as) it leaves it at 0,
+	// which net/http treats as an explicit empty body rather than "unknown".
+	// Tell it the real length when the peer declared one, or that the length
+	// is unknown so it falls back to chunked transfer-encoding.
+	if body != nil {
+		req.ContentLength = -1
+		if cl, ok := caseInsensitiveGet(msg.Headers, "content-length"); ok {
+			if n, err := strconv.ParseInt(cl, 10, 64); err == nil {
+				req.ContentLength = n
+			}
+		}
+	}
+
 	for key, value := range msg.Headers {
 		if skipHeaders[strings.ToLower(key)] {
 			continue
@@ -46,7 +94,31 @@ func ProxyRequest(host string, port int, msg *protocol.HttpRequestMessage, body
 		req.Header.Set(key, value)
 	}
 
+	transport := &http.Transport{
+		// net/http's default transport transparently requests and decodes
+		// gzip on our behalf, which would race with decompressBody below
+		// and make preserveEncoding impossible to honor for gzip alone.
+		// Disable it so every codec goes through the same explicit path.
+		DisableCompression: true,
+	}
+	if scheme == "https" && insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		// http.ProxyFromEnvironment reads HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+		// once per process and caches the result, which makes it impossible
+		// to exercise from tests that set these vars per-case. Read the
+		// environment fresh on every call instead.
+		envConfig := httpproxy.FromEnvironment()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return envConfig.ProxyFunc()(req.URL)
+		}
+	}
+
 	client := &http.Client{
+		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -54,39 +126,128 @@ func ProxyRequest(host string, port int, msg *protocol.HttpRequestMessage, body
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to reach localhost:%d: %w", port, err)
+		return nil, fmt.Errorf("failed to reach %s:%d: %w", host, port, err)
 	}
-	defer resp.Body.Close()
 
-	// Check content-length before reading
+	// Check content-length before streaming so callers fail fast without
+	// opening a body they'll just have to abort.
 	if cl := resp.Header.Get("Content-Length"); cl != "" {
 		if size, err := strconv.ParseInt(cl, 10, 64); err == nil && size > int64(maxBodySize) {
+			resp.Body.Close()
 			return nil, &BodyTooLargeError{Limit: maxBodySize}
 		}
 	}
 
-	// Read body with limit
-	respBody, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBodySize)+1))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if len(respBody) > maxBodySize {
-		return nil, &BodyTooLargeError{Limit: maxBodySize}
-	}
-
 	headers := make(map[string]string)
 	for key := range resp.Header {
 		headers[key] = resp.Header.Get(key)
 	}
 
+	respBody := resp.Body
+	decoded := false
+
+	if !preserveEncoding {
+		dec, err := decompressBody(resp.Header.Get("Content-Encoding"), resp.Body, maxBodySize)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to decompress response body: %w", err)
+		}
+		if dec != nil {
+			respBody = dec
+			decoded = true
+			delete(headers, "Content-Encoding")
+			delete(headers, "Content-Length")
+		}
+	}
+
 	return &ProxyResponse{
 		Status:  resp.StatusCode,
 		Headers: headers,
 		Body:    respBody,
+		Decoded: decoded,
 	}, nil
 }
 
+// decompressBody returns a ReadCloser that transparently decompresses body
+// per encoding (gzip, deflate, br, or zstd), or nil if encoding isn't one of
+// those (in which case the caller keeps forwarding body as-is). The returned
+// reader enforces maxBodySize against the decompressed byte count, failing
+// with a *BodyTooLargeError once exceeded. Closing it also closes body.
+func decompressBody(encoding string, body io.ReadCloser, maxBodySize int) (io.ReadCloser, error) {
+	bounded := &boundedReader{limit: maxBodySize}
+
+	switch strings.ToLower(encoding) {
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		bounded.r = gz
+		return &decodingReadCloser{Reader: bounded, closers: []io.Closer{gz, body}}, nil
+
+	case "deflate":
+		fl := flate.NewReader(body)
+		bounded.r = fl
+		return &decodingReadCloser{Reader: bounded, closers: []io.Closer{fl, body}}, nil
+
+	case "br":
+		bounded.r = brotli.NewReader(body)
+		return &decodingReadCloser{Reader: bounded, closers: []io.Closer{body}}, nil
+
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		bounded.r = zr
+		return &decodingReadCloser{Reader: bounded, closers: []io.Closer{closerFunc(func() error { zr.Close(); return nil }), body}}, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// boundedReader wraps a decompressing reader and fails once more than limit
+// bytes have come out of it, so maxBodySize is enforced against the
+// decompressed size instead of the (often much smaller) wire size.
+type boundedReader struct {
+	r     io.Reader
+	limit int
+	read  int
+}
+
+func (b *boundedReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += n
+	if b.read > b.limit {
+		return n, &BodyTooLargeError{Limit: b.limit}
+	}
+	return n, err
+}
+
+// decodingReadCloser pairs a decompressing Reader with the Closers that need
+// tearing down alongside it (the decompressor itself, where it has one, and
+// the underlying compressed body).
+type decodingReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decodingReadCloser) Close() error {
+	var firstErr error
+	for _, c := range d.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// closerFunc adapts a plain func() error to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
 // BodyTooLargeError indicates the response body exceeded the size limit.
 type BodyTooLargeError struct {
 	Limit int
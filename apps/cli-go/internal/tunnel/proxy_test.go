@@ -1,12 +1,22 @@
 package tunnel
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/nkootstra/xpose/internal/protocol"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,17 +47,20 @@ func TestProxyRequest_Success(t *testing.T) {
 
 	host, port := parseHostPort(server.URL)
 
-	resp, err := ProxyRequest(host, port, &protocol.HttpRequestMessage{
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
 		Type:    "http-request",
 		ID:      "req-1",
 		Method:  "GET",
 		Path:    "/test",
 		Headers: map[string]string{},
-	}, nil, 5*1024*1024)
+	}, nil, 5*1024*1024, false, nil, "http", false)
 
 	require.NoError(t, err)
+	defer resp.Body.Close()
 	assert.Equal(t, 200, resp.Status)
-	assert.Equal(t, "hello world", string(resp.Body))
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(gotBody))
 	assert.Equal(t, "value", resp.Headers["X-Custom"])
 }
 
@@ -64,55 +77,73 @@ func TestProxyRequest_PostWithBody(t *testing.T) {
 
 	host, port := parseHostPort(server.URL)
 
-	resp, err := ProxyRequest(host, port, &protocol.HttpRequestMessage{
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
 		Type:    "http-request",
 		ID:      "req-2",
 		Method:  "POST",
 		Path:    "/submit",
 		Headers: map[string]string{"Content-Type": "application/json"},
 		HasBody: true,
-	}, []byte(`{"key":"value"}`), 5*1024*1024)
+	}, strings.NewReader(`{"key":"value"}`), 5*1024*1024, false, nil, "http", false)
 
 	require.NoError(t, err)
+	defer resp.Body.Close()
 	assert.Equal(t, 200, resp.Status)
-	assert.Equal(t, `{"key":"value"}`, string(resp.Body))
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"value"}`, string(gotBody))
 }
 
 func TestProxyRequest_ConnectionRefused(t *testing.T) {
-	_, err := ProxyRequest("127.0.0.1", 1, &protocol.HttpRequestMessage{
+	_, err := ProxyRequest(context.Background(), "127.0.0.1", 1, &protocol.HttpRequestMessage{
 		Type:    "http-request",
 		ID:      "req-3",
 		Method:  "GET",
 		Path:    "/",
 		Headers: map[string]string{},
-	}, nil, 5*1024*1024)
+	}, nil, 5*1024*1024, false, nil, "http", false)
 
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to reach localhost:1")
+	assert.Contains(t, err.Error(), "failed to reach 127.0.0.1:1")
 }
 
-func TestProxyRequest_OversizedResponse(t *testing.T) {
+func TestProxyRequest_OversizedResponseWithoutContentLength(t *testing.T) {
+	// Without a Content-Length header, ProxyRequest can't know the body size
+	// upfront, so it streams the response back and leaves cap enforcement
+	// (the 100-byte limit here) to whatever pumps resp.Body.
 	bigBody := strings.Repeat("x", 1024)
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flushing before the handler returns forces net/http to switch to
+		// chunked transfer encoding rather than computing and setting a
+		// Content-Length header, so this actually exercises the
+		// no-Content-Length streaming path instead of tripping the
+		// Content-Length fast path in ProxyRequest.
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("ResponseWriter does not support flushing")
+		}
 		if _, err := w.Write([]byte(bigBody)); err != nil {
 			t.Errorf("failed to write oversized response body: %v", err)
 		}
+		flusher.Flush()
 	}))
 	defer server.Close()
 
 	host, port := parseHostPort(server.URL)
 
-	_, err := ProxyRequest(host, port, &protocol.HttpRequestMessage{
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
 		Type:    "http-request",
 		ID:      "req-4",
 		Method:  "GET",
 		Path:    "/",
 		Headers: map[string]string{},
-	}, nil, 100) // 100 byte limit
+	}, nil, 100, false, nil, "http", false) // 100 byte limit, not enforceable without Content-Length
 
-	require.Error(t, err)
-	_, ok := err.(*BodyTooLargeError)
-	assert.True(t, ok, "expected BodyTooLargeError")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, bigBody, string(gotBody))
 }
 
 func TestProxyRequest_OversizedResponseContentLength(t *testing.T) {
@@ -124,13 +155,13 @@ func TestProxyRequest_OversizedResponseContentLength(t *testing.T) {
 
 	host, port := parseHostPort(server.URL)
 
-	_, err := ProxyRequest(host, port, &protocol.HttpRequestMessage{
+	_, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
 		Type:    "http-request",
 		ID:      "req-5",
 		Method:  "GET",
 		Path:    "/",
 		Headers: map[string]string{},
-	}, nil, 100)
+	}, nil, 100, false, nil, "http", false)
 
 	require.Error(t, err)
 	_, ok := err.(*BodyTooLargeError)
@@ -149,7 +180,7 @@ func TestProxyRequest_SkipsHopByHopHeaders(t *testing.T) {
 
 	host, port := parseHostPort(server.URL)
 
-	resp, err := ProxyRequest(host, port, &protocol.HttpRequestMessage{
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
 		Type:   "http-request",
 		ID:     "req-6",
 		Method: "GET",
@@ -160,7 +191,7 @@ func TestProxyRequest_SkipsHopByHopHeaders(t *testing.T) {
 			"Transfer-Encoding": "chunked",
 			"X-Custom":          "keep-me",
 		},
-	}, nil, 5*1024*1024)
+	}, nil, 5*1024*1024, false, nil, "http", false)
 
 	require.NoError(t, err)
 	assert.Equal(t, 200, resp.Status)
@@ -174,14 +205,378 @@ func TestProxyRequest_FollowsNoRedirects(t *testing.T) {
 
 	host, port := parseHostPort(server.URL)
 
-	resp, err := ProxyRequest(host, port, &protocol.HttpRequestMessage{
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
 		Type:    "http-request",
 		ID:      "req-7",
 		Method:  "GET",
 		Path:    "/",
 		Headers: map[string]string{},
-	}, nil, 5*1024*1024)
+	}, nil, 5*1024*1024, false, nil, "http", false)
 
 	require.NoError(t, err)
 	assert.Equal(t, 302, resp.Status)
 }
+
+func TestProxyRequest_CancelContextAbortsRoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	host, port := parseHostPort(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ProxyRequest(ctx, host, port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-8",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, false, nil, "http", false)
+
+	require.Error(t, err)
+}
+
+// compressWith returns data compressed with the named encoding (gzip,
+// deflate, br, or zstd), for feeding to a test server as a Content-Encoding
+// response body.
+func compressWith(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	switch encoding {
+	case "gzip":
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+	case "deflate":
+		fl, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		_, err = fl.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, fl.Close())
+	case "br":
+		br := brotli.NewWriter(&buf)
+		_, err := br.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, br.Close())
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		_, err = zw.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+	default:
+		t.Fatalf("compressWith: unsupported encoding %q", encoding)
+	}
+	return buf.Bytes()
+}
+
+func TestProxyRequest_DecompressesResponseBody(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			want := strings.Repeat("decompress me please ", 50)
+			compressed := compressWith(t, encoding, []byte(want))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", encoding)
+				if _, err := w.Write(compressed); err != nil {
+					t.Errorf("failed to write compressed response body: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			host, port := parseHostPort(server.URL)
+
+			resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+				Type:    "http-request",
+				ID:      "req-9",
+				Method:  "GET",
+				Path:    "/",
+				Headers: map[string]string{},
+			}, nil, 5*1024*1024, false, nil, "http", false)
+
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.True(t, resp.Decoded)
+			assert.NotContains(t, resp.Headers, "Content-Encoding")
+			gotBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(gotBody))
+		})
+	}
+}
+
+func TestProxyRequest_OversizedDecompressedBody(t *testing.T) {
+	// Mirrors TestProxyRequest_OversizedResponseWithoutContentLength, but the
+	// limit is only exceeded once the body is decompressed: the compressed
+	// bytes on the wire are well under maxBodySize.
+	for _, encoding := range []string{"gzip", "deflate", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			bigBody := strings.Repeat("x", 1024)
+			compressed := compressWith(t, encoding, []byte(bigBody))
+			require.Less(t, len(compressed), 100, "fixture should compress well under the test's maxBodySize")
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", encoding)
+				if _, err := w.Write(compressed); err != nil {
+					t.Errorf("failed to write compressed response body: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			host, port := parseHostPort(server.URL)
+
+			resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+				Type:    "http-request",
+				ID:      "req-10",
+				Method:  "GET",
+				Path:    "/",
+				Headers: map[string]string{},
+			}, nil, 100, false, nil, "http", false)
+
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			_, err = io.ReadAll(resp.Body)
+			require.Error(t, err)
+			_, ok := err.(*BodyTooLargeError)
+			assert.True(t, ok, "expected BodyTooLargeError")
+		})
+	}
+}
+
+func TestProxyRequest_PreserveEncodingSkipsDecompression(t *testing.T) {
+	want := strings.Repeat("leave me encoded ", 50)
+	compressed := compressWith(t, "gzip", []byte(want))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		if _, err := w.Write(compressed); err != nil {
+			t.Errorf("failed to write compressed response body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	host, port := parseHostPort(server.URL)
+
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-11",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, true, nil, "http", false)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.False(t, resp.Decoded)
+	assert.Equal(t, "gzip", resp.Headers["Content-Encoding"])
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, compressed, gotBody)
+}
+
+// newForwardProxy starts an httptest.Server that plays the role of a plain
+// HTTP forward proxy: it forwards whatever absolute-form request it receives
+// to target, optionally demanding the given Basic credentials first. Pass an
+// empty wantUser to skip the auth check.
+func newForwardProxy(t *testing.T, target *httptest.Server, wantUser, wantPass string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantUser != "" {
+			user, pass, ok := parseProxyBasicAuth(r.Header.Get("Proxy-Authorization"))
+			if !ok || user != wantUser || pass != wantPass {
+				w.Header().Set("Proxy-Authenticate", `Basic realm="xpose-test-proxy"`)
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+		}
+
+		upstream, err := http.Get(target.URL + r.URL.Path)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer upstream.Body.Close()
+		body, err := io.ReadAll(upstream.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if _, err := w.Write(body); err != nil {
+			t.Errorf("failed to write proxied response body: %v", err)
+		}
+	}))
+}
+
+func parseProxyBasicAuth(header string) (user, pass string, ok bool) {
+	const prefix = "Basic "
+	if !strings.HasPrefix(header, prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	user, pass, ok = strings.Cut(string(decoded), ":")
+	return user, pass, ok
+}
+
+func TestProxyRequest_ExplicitProxyURLRoutesThroughProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("via-proxy")); err != nil {
+			t.Errorf("failed to write target response body: %v", err)
+		}
+	}))
+	defer target.Close()
+
+	proxy := newForwardProxy(t, target, "", "")
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	host, port := parseHostPort(target.URL)
+
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-12",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, false, proxyURL, "http", false)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "via-proxy", string(gotBody))
+}
+
+func TestProxyRequest_ProxyAuthRequiredSendsBasicCredsFromURL(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("authenticated")); err != nil {
+			t.Errorf("failed to write target response body: %v", err)
+		}
+	}))
+	defer target.Close()
+
+	proxy := newForwardProxy(t, target, "xpose", "s3cret")
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	proxyURL.User = url.UserPassword("xpose", "s3cret")
+
+	host, port := parseHostPort(target.URL)
+
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-13",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, false, proxyURL, "http", false)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "authenticated", string(gotBody))
+}
+
+func TestProxyRequest_ProxyAuthRequiredWithoutCredsFails(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer target.Close()
+
+	proxy := newForwardProxy(t, target, "xpose", "s3cret")
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	host, port := parseHostPort(target.URL)
+
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-14",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, false, proxyURL, "http", false)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusProxyAuthRequired, resp.Status)
+}
+
+func TestProxyRequest_HonorsHttpProxyEnvVar(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("via-env-proxy")); err != nil {
+			t.Errorf("failed to write target response body: %v", err)
+		}
+	}))
+	defer target.Close()
+
+	proxy := newForwardProxy(t, target, "", "")
+	defer proxy.Close()
+
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "")
+
+	// The environment lookup never proxies a loopback target, so dial a
+	// name that only the forward proxy can reach (it ignores the requested
+	// host and always forwards to target).
+	_, port := parseHostPort(target.URL)
+
+	resp, err := ProxyRequest(context.Background(), "xpose-target.invalid", port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-15",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, false, nil, "http", false)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "via-env-proxy", string(gotBody))
+}
+
+func TestProxyRequest_NoProxyBypassesHttpProxyEnvVar(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("direct")); err != nil {
+			t.Errorf("failed to write target response body: %v", err)
+		}
+	}))
+	defer target.Close()
+
+	host, port := parseHostPort(target.URL)
+
+	// Point HTTP_PROXY at an address nothing is listening on, so a dial
+	// through it would fail outright; NO_PROXY for the target host should
+	// make ProxyRequest skip the proxy and dial direct.
+	t.Setenv("HTTP_PROXY", "http://127.0.0.1:1")
+	t.Setenv("NO_PROXY", host)
+
+	resp, err := ProxyRequest(context.Background(), host, port, &protocol.HttpRequestMessage{
+		Type:    "http-request",
+		ID:      "req-16",
+		Method:  "GET",
+		Path:    "/",
+		Headers: map[string]string{},
+	}, nil, 5*1024*1024, false, nil, "http", false)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	gotBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "direct", string(gotBody))
+}
@@ -0,0 +1,190 @@
+package tunnel
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/coder/websocket"
+
+	"github.com/nkootstra/xpose/internal/protocol"
+)
+
+// requestStreamChunkBacklog bounds how many not-yet-pumped chunks a
+// requestStream will queue before handleRequestBodyChunk starts blocking the
+// connection's read loop. It only matters if the peer ignores the granted
+// window, since under normal operation the window keeps far fewer chunks
+// than this in flight at once.
+const requestStreamChunkBacklog = 64
+
+// requestStream feeds an inbound HTTP request body into a local ProxyRequest
+// call as chunks arrive over the tunnel, instead of buffering the whole body
+// in memory first. A dedicated pump goroutine (see Client.pumpRequestBody)
+// drains chunks into pw, so a slow local server naturally backs up that
+// goroutine rather than the connection's read loop; as the pump drains
+// chunks it grants the peer more send credit via HttpFlowControlMessage,
+// bounding how much of the body the client buffers at once regardless of
+// the total MaxBodySizeBytes ceiling.
+type requestStream struct {
+	pw     *io.PipeWriter
+	chunks chan []byte
+
+	mu                  sync.Mutex
+	rawPW               *io.PipeWriter // non-nil once setEncoding wires up a compression codec; receives the still-encoded bytes, decoded into pw by a background goroutine
+	received            int
+	maxBody             int
+	granted             int
+	consumedSinceUpdate int
+	oversized           bool
+	closeOnce           sync.Once
+}
+
+// newRequestStream creates a requestStream with an initial receive window of
+// protocol.DefaultRequestWindowSizeBytes. The stream assumes an identity
+// (uncompressed) body until setEncoding says otherwise, since the peer only
+// reveals a request body's wire codec via the Encoding field on its first
+// HttpBodyChunkMessage, which arrives after the stream is created.
+func newRequestStream(pw *io.PipeWriter, maxBody int) *requestStream {
+	return &requestStream{
+		pw:      pw,
+		chunks:  make(chan []byte, requestStreamChunkBacklog),
+		maxBody: maxBody,
+		granted: protocol.DefaultRequestWindowSizeBytes,
+	}
+}
+
+// setEncoding wires up decoding for a wire-level compression codec the peer
+// negotiated for this request body (currently only "gzip"; identity is a
+// no-op). It's safe to call once per stream before any chunk bytes are
+// accepted; later calls are ignored, since a peer sends the same encoding
+// for every chunk of one request body.
+func (rs *requestStream) setEncoding(encoding string, maxBody int) {
+	if encoding != "gzip" {
+		return
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.rawPW != nil {
+		return
+	}
+
+	pw := rs.pw
+	rawPR, rawPW := io.Pipe()
+	rs.rawPW = rawPW
+	go func() {
+		gz, err := gzip.NewReader(rawPR)
+		if err != nil {
+			rawPR.CloseWithError(err)
+			pw.CloseWithError(fmt.Errorf("failed to decode gzip request body: %w", err))
+			return
+		}
+		bounded := &boundedReader{r: gz, limit: maxBody}
+		if _, err := io.Copy(pw, bounded); err != nil {
+			rawPR.CloseWithError(err)
+			pw.CloseWithError(err)
+			return
+		}
+		pw.Close()
+	}()
+}
+
+// writer returns the destination for incoming wire bytes: rawPW once
+// setEncoding has wired up a decoding goroutine, otherwise pw directly.
+func (rs *requestStream) writer() *io.PipeWriter {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.rawPW != nil {
+		return rs.rawPW
+	}
+	return rs.pw
+}
+
+// accept records an incoming chunk's bytes and queues it for the pump
+// goroutine. It returns an error once the stream's total received bytes
+// exceed maxBody; the caller must abort the stream in that case.
+func (rs *requestStream) accept(ctx context.Context, body []byte) error {
+	rs.mu.Lock()
+	rs.received += len(body)
+	tooLarge := rs.received > rs.maxBody
+	rs.mu.Unlock()
+
+	if tooLarge {
+		return fmt.Errorf("request body exceeds %d byte limit", rs.maxBody)
+	}
+
+	select {
+	case rs.chunks <- body:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeChunks signals the pump goroutine that no more chunks are coming, so
+// it finishes draining and closes pw cleanly. Safe to call more than once.
+func (rs *requestStream) closeChunks() {
+	rs.closeOnce.Do(func() {
+		close(rs.chunks)
+	})
+}
+
+// pumpRequestBody drains rs.chunks into rs.pw, replenishing the peer's send
+// credit via HttpFlowControlMessage as bytes are actually consumed (i.e. as
+// pw.Write returns, meaning the local HTTP client read them). It exits once
+// the channel is closed or a write fails, e.g. because ProxyRequest's
+// request already completed or was canceled.
+func (c *Client) pumpRequestBody(ctx context.Context, conn *websocket.Conn, requestID string, rs *requestStream) {
+	for chunk := range rs.chunks {
+		if _, err := rs.writer().Write(chunk); err != nil {
+			return
+		}
+
+		rs.mu.Lock()
+		rs.consumedSinceUpdate += len(chunk)
+		var increment int
+		if rs.consumedSinceUpdate >= rs.granted/2 {
+			increment = rs.consumedSinceUpdate
+			rs.granted += increment
+			rs.consumedSinceUpdate = 0
+		}
+		rs.mu.Unlock()
+
+		if increment > 0 {
+			c.sendJSON(ctx, conn, &protocol.HttpFlowControlMessage{
+				Type:            "http-flow-control",
+				ID:              requestID,
+				WindowIncrement: increment,
+			})
+		}
+	}
+	rs.writer().Close()
+}
+
+// abortRequestStream tears down any in-flight proxy and buffered body state
+// for requestID, e.g. on a peer-sent HttpCancelMessage or an oversized
+// request body. Closing pw with an error unblocks a pending pw.Write in the
+// pump goroutine and causes ProxyRequest's body read to fail.
+func (c *Client) abortRequestStream(requestID, reason string) {
+	c.mu.Lock()
+	rs := c.requestStreams[requestID]
+	delete(c.requestStreams, requestID)
+	cancel := c.activeCancels[requestID]
+	delete(c.activeCancels, requestID)
+	c.mu.Unlock()
+
+	if rs != nil {
+		rs.mu.Lock()
+		rawPW := rs.rawPW
+		rs.mu.Unlock()
+		if rawPW != nil {
+			rawPW.CloseWithError(fmt.Errorf("%s", reason))
+		}
+		rs.pw.CloseWithError(fmt.Errorf("%s", reason))
+	}
+	if cancel != nil {
+		cancel()
+	}
+}
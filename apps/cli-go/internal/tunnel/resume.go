@@ -0,0 +1,100 @@
+package tunnel
+
+import "sync"
+
+// defaultResumeBufferEntries and defaultResumeBufferBytes bound the resume
+// ring buffer when ClientOptions doesn't override them.
+const (
+	defaultResumeBufferEntries = 256
+	defaultResumeBufferBytes   = 1 * 1024 * 1024
+)
+
+// responseRecord is one completed response the client has already sent
+// once (its terminal http-response-end or error message, pre-marshaled),
+// kept around so it can be replayed verbatim if the connection drops
+// before the server processes it.
+type responseRecord struct {
+	Seq       uint64
+	RequestID string
+	Data      []byte
+}
+
+// resumeBuffer is a bounded ring buffer of responseRecords, capped on both
+// entry count and total byte size so a burst of completed requests can't
+// grow it without limit. The zero value is not usable; use newResumeBuffer.
+type resumeBuffer struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	totalBytes int
+	entries    []responseRecord
+}
+
+// newResumeBuffer creates a resumeBuffer, falling back to the package
+// defaults for either cap left at zero.
+func newResumeBuffer(maxEntries, maxBytes int) *resumeBuffer {
+	if maxEntries <= 0 {
+		maxEntries = defaultResumeBufferEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultResumeBufferBytes
+	}
+	return &resumeBuffer{maxEntries: maxEntries, maxBytes: maxBytes}
+}
+
+// record appends a completed response, evicting the oldest entries if the
+// buffer has grown past its count or byte cap.
+func (b *resumeBuffer) record(rec responseRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, rec)
+	b.totalBytes += len(rec.Data)
+	for len(b.entries) > 0 && (len(b.entries) > b.maxEntries || b.totalBytes > b.maxBytes) {
+		b.totalBytes -= len(b.entries[0].Data)
+		b.entries = b.entries[1:]
+	}
+}
+
+// highestSeq returns the most recently recorded sequence number, or 0 if
+// nothing has been recorded yet.
+func (b *resumeBuffer) highestSeq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) == 0 {
+		return 0
+	}
+	return b.entries[len(b.entries)-1].Seq
+}
+
+// replayFor returns the buffered records whose request ID appears in ids,
+// in the order they were recorded, for replaying to a resumed connection.
+func (b *resumeBuffer) replayFor(ids []string) []responseRecord {
+	if len(ids) == 0 {
+		return nil
+	}
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []responseRecord
+	for _, e := range b.entries {
+		if want[e.RequestID] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// reset clears the buffer, e.g. when a reconnect starts a fresh session
+// rather than resuming the previous one.
+func (b *resumeBuffer) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+	b.totalBytes = 0
+}
@@ -0,0 +1,66 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeBuffer_HighestSeqReflectsLastRecord(t *testing.T) {
+	b := newResumeBuffer(0, 0)
+	assert.Equal(t, uint64(0), b.highestSeq())
+
+	b.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte("a")})
+	b.record(responseRecord{Seq: 2, RequestID: "req-2", Data: []byte("b")})
+	assert.Equal(t, uint64(2), b.highestSeq())
+}
+
+func TestResumeBuffer_ReplayForFiltersByRequestID(t *testing.T) {
+	b := newResumeBuffer(0, 0)
+	b.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte("a")})
+	b.record(responseRecord{Seq: 2, RequestID: "req-2", Data: []byte("b")})
+	b.record(responseRecord{Seq: 3, RequestID: "req-3", Data: []byte("c")})
+
+	got := b.replayFor([]string{"req-1", "req-3"})
+	assert.Len(t, got, 2)
+	assert.Equal(t, "req-1", got[0].RequestID)
+	assert.Equal(t, "req-3", got[1].RequestID)
+}
+
+func TestResumeBuffer_ReplayForEmptyIDsReturnsNil(t *testing.T) {
+	b := newResumeBuffer(0, 0)
+	b.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte("a")})
+
+	assert.Empty(t, b.replayFor(nil))
+}
+
+func TestResumeBuffer_EvictsOldestEntriesPastEntryCap(t *testing.T) {
+	b := newResumeBuffer(2, 0)
+	b.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte("a")})
+	b.record(responseRecord{Seq: 2, RequestID: "req-2", Data: []byte("b")})
+	b.record(responseRecord{Seq: 3, RequestID: "req-3", Data: []byte("c")})
+
+	got := b.replayFor([]string{"req-1", "req-2", "req-3"})
+	assert.Len(t, got, 2)
+	assert.Equal(t, "req-2", got[0].RequestID)
+	assert.Equal(t, "req-3", got[1].RequestID)
+}
+
+func TestResumeBuffer_EvictsOldestEntriesPastByteCap(t *testing.T) {
+	b := newResumeBuffer(0, 5)
+	b.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte("abc")})
+	b.record(responseRecord{Seq: 2, RequestID: "req-2", Data: []byte("abc")})
+
+	got := b.replayFor([]string{"req-1", "req-2"})
+	assert.Len(t, got, 1)
+	assert.Equal(t, "req-2", got[0].RequestID)
+}
+
+func TestResumeBuffer_ResetClearsEntries(t *testing.T) {
+	b := newResumeBuffer(0, 0)
+	b.record(responseRecord{Seq: 1, RequestID: "req-1", Data: []byte("a")})
+	b.reset()
+
+	assert.Equal(t, uint64(0), b.highestSeq())
+	assert.Empty(t, b.replayFor([]string{"req-1"}))
+}
@@ -0,0 +1,61 @@
+package tunnel
+
+import "strings"
+
+// Route maps a request path prefix to a local backend, so a single tunnel
+// can fan out to several local servers (e.g. one per app in a monorepo)
+// instead of requiring one tunnel per target. PathPrefix "" matches every
+// path and serves as the default/fallback route.
+type Route struct {
+	PathPrefix  string
+	Host        string
+	Port        int
+	StripPrefix bool // if set, PathPrefix is removed from the forwarded path
+}
+
+// resolveRoute picks the route whose PathPrefix longest-matches path,
+// mirroring Tailscale's serve-style handler table. It returns the dial
+// target, the path to forward (with PathPrefix stripped if requested), and
+// the matched route so callers can surface it on TunnelEvent.Traffic. If
+// routes is empty, ok is false and callers should fall back to their own
+// single Host/Port.
+func resolveRoute(routes []Route, path string) (target Route, forwardPath string, ok bool) {
+	best := -1
+	for i, r := range routes {
+		if !pathPrefixMatch(path, r.PathPrefix) {
+			continue
+		}
+		if best == -1 || len(r.PathPrefix) > len(routes[best].PathPrefix) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return Route{}, path, false
+	}
+
+	matched := routes[best]
+	forwardPath = path
+	if matched.StripPrefix && matched.PathPrefix != "" {
+		forwardPath = strings.TrimPrefix(path, matched.PathPrefix)
+		if forwardPath == "" || forwardPath[0] != '/' {
+			forwardPath = "/" + forwardPath
+		}
+	}
+	return matched, forwardPath, true
+}
+
+// pathPrefixMatch reports whether prefix matches path on a path-segment
+// boundary, so a route for "/web" doesn't also claim "/webinar". The empty
+// prefix matches everything (the default/fallback route).
+func pathPrefixMatch(path, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	if len(path) == len(prefix) {
+		return true
+	}
+	return path[len(prefix)] == '/'
+}
@@ -0,0 +1,87 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveRoute_LongestPrefixWins(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/api", Host: "localhost", Port: 8787},
+		{PathPrefix: "/api/v2", Host: "localhost", Port: 8788},
+	}
+
+	matched, _, ok := resolveRoute(routes, "/api/v2/widgets")
+	assert.True(t, ok)
+	assert.Equal(t, 8788, matched.Port)
+}
+
+func TestResolveRoute_StripPrefixRewritesPath(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/docs", Host: "localhost", Port: 5173, StripPrefix: true},
+	}
+
+	matched, forwardPath, ok := resolveRoute(routes, "/docs/getting-started")
+	assert.True(t, ok)
+	assert.Equal(t, "/docs", matched.PathPrefix)
+	assert.Equal(t, "/getting-started", forwardPath)
+}
+
+func TestResolveRoute_StripPrefixExactMatchLeavesRootSlash(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/docs", Host: "localhost", Port: 5173, StripPrefix: true},
+	}
+
+	_, forwardPath, ok := resolveRoute(routes, "/docs")
+	assert.True(t, ok)
+	assert.Equal(t, "/", forwardPath)
+}
+
+func TestResolveRoute_NoStripPrefixLeavesPathUnchanged(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/web", Host: "localhost", Port: 3000},
+	}
+
+	_, forwardPath, ok := resolveRoute(routes, "/web/dashboard")
+	assert.True(t, ok)
+	assert.Equal(t, "/web/dashboard", forwardPath)
+}
+
+func TestResolveRoute_FallsBackToDefaultRoute(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/web", Host: "localhost", Port: 3000},
+		{PathPrefix: "", Host: "localhost", Port: 8787},
+	}
+
+	matched, forwardPath, ok := resolveRoute(routes, "/unmatched")
+	assert.True(t, ok)
+	assert.Equal(t, 8787, matched.Port)
+	assert.Equal(t, "/unmatched", forwardPath)
+}
+
+func TestResolveRoute_EmptyRoutesReturnsNotOk(t *testing.T) {
+	_, forwardPath, ok := resolveRoute(nil, "/anything")
+	assert.False(t, ok)
+	assert.Equal(t, "/anything", forwardPath)
+}
+
+func TestResolveRoute_NoMatchReturnsNotOk(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/web", Host: "localhost", Port: 3000},
+	}
+
+	_, _, ok := resolveRoute(routes, "/docs")
+	assert.False(t, ok)
+}
+
+func TestResolveRoute_DoesNotMatchOnSharedTextPrefixAcrossSegments(t *testing.T) {
+	routes := []Route{
+		{PathPrefix: "/web", Host: "localhost", Port: 3000},
+		{PathPrefix: "", Host: "localhost", Port: 8787},
+	}
+
+	matched, _, ok := resolveRoute(routes, "/webinar/signup")
+	assert.True(t, ok)
+	assert.Equal(t, 8787, matched.Port)
+}
@@ -0,0 +1,171 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// SOCKS5 protocol constants, per RFC 1928. Only the no-authentication method
+// and the CONNECT command are implemented; that covers every client xpose
+// needs to support (ssh -D, curl --socks5, browser proxy settings, ...).
+const (
+	socks5Version = 0x05
+
+	socks5AuthNone         = 0x00
+	socks5AuthNoAcceptable = 0xff
+
+	socks5CmdConnect = 0x01
+
+	socks5AtypIPv4   = 0x01
+	socks5AtypDomain = 0x03
+	socks5AtypIPv6   = 0x04
+
+	socks5ReplySucceeded           = 0x00
+	socks5ReplyGeneralFailure      = 0x01
+	socks5ReplyCommandNotSupported = 0x07
+)
+
+// serveSocks5 runs a minimal in-process SOCKS5 server over conn: it performs
+// the handshake, dials the requested destination, and then splices bytes
+// between conn and the destination until either side closes. conn is always
+// closed before this returns. It's the socks-mode counterpart to dialing
+// host:port directly in handleOpen for tcp mode — here the destination comes
+// from the SOCKS5 request instead of being fixed in advance. The dial is
+// bound to ctx so tearing down the owning relay (handleClose/closeAll)
+// aborts a slow or hanging CONNECT instead of leaking it until the OS-level
+// dial timeout fires.
+func serveSocks5(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	target, err := socks5Handshake(conn)
+	if err != nil {
+		return
+	}
+
+	var d net.Dialer
+	dest, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		writeSocks5Reply(conn, socks5ReplyGeneralFailure)
+		return
+	}
+	defer dest.Close()
+
+	if err := writeSocks5Reply(conn, socks5ReplySucceeded); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(dest, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, dest)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// socks5Handshake reads the client greeting and CONNECT request from conn and
+// returns the requested destination as "host:port". Only the no-auth method
+// and CONNECT are accepted; anything else is rejected per RFC 1928 before the
+// connection is torn down by the caller.
+func socks5Handshake(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("socks5: read greeting: %w", err)
+	}
+	if header[0] != socks5Version {
+		return "", fmt.Errorf("socks5: unsupported version %d", header[0])
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", fmt.Errorf("socks5: read auth methods: %w", err)
+	}
+
+	supportsNoAuth := false
+	for _, m := range methods {
+		if m == socks5AuthNone {
+			supportsNoAuth = true
+			break
+		}
+	}
+	if !supportsNoAuth {
+		conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+		return "", fmt.Errorf("socks5: client offered no acceptable auth method")
+	}
+	if _, err := conn.Write([]byte{socks5Version, socks5AuthNone}); err != nil {
+		return "", fmt.Errorf("socks5: write auth reply: %w", err)
+	}
+
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return "", fmt.Errorf("socks5: read request: %w", err)
+	}
+	if req[0] != socks5Version {
+		return "", fmt.Errorf("socks5: unsupported version %d in request", req[0])
+	}
+	if req[1] != socks5CmdConnect {
+		writeSocks5Reply(conn, socks5ReplyCommandNotSupported)
+		return "", fmt.Errorf("socks5: unsupported command %d", req[1])
+	}
+
+	host, err := readSocks5Addr(conn, req[3])
+	if err != nil {
+		return "", err
+	}
+
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBytes); err != nil {
+		return "", fmt.Errorf("socks5: read port: %w", err)
+	}
+	port := binary.BigEndian.Uint16(portBytes)
+
+	return net.JoinHostPort(host, fmt.Sprintf("%d", port)), nil
+}
+
+// readSocks5Addr reads a DST.ADDR field whose encoding is selected by atyp.
+func readSocks5Addr(conn net.Conn, atyp byte) (string, error) {
+	switch atyp {
+	case socks5AtypIPv4:
+		addr := make([]byte, 4)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return "", fmt.Errorf("socks5: read ipv4 addr: %w", err)
+		}
+		return net.IP(addr).String(), nil
+	case socks5AtypIPv6:
+		addr := make([]byte, 16)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return "", fmt.Errorf("socks5: read ipv6 addr: %w", err)
+		}
+		return net.IP(addr).String(), nil
+	case socks5AtypDomain:
+		lenByte := make([]byte, 1)
+		if _, err := io.ReadFull(conn, lenByte); err != nil {
+			return "", fmt.Errorf("socks5: read domain length: %w", err)
+		}
+		domain := make([]byte, lenByte[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", fmt.Errorf("socks5: read domain: %w", err)
+		}
+		return string(domain), nil
+	default:
+		return "", fmt.Errorf("socks5: unsupported address type %d", atyp)
+	}
+}
+
+// writeSocks5Reply sends a CONNECT reply with the given status code and a
+// zero-value BND.ADDR/BND.PORT, since xpose never needs the client to dial
+// back to a specific bound address.
+func writeSocks5Reply(conn net.Conn, reply byte) error {
+	_, err := conn.Write([]byte{
+		socks5Version, reply, 0x00, socks5AtypIPv4,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00,
+	})
+	return err
+}
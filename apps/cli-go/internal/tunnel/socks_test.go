@@ -0,0 +1,134 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socks5ConnectRequest builds the CONNECT request body (everything after the
+// greeting/method-select round trip) for host:port, as a real client would
+// send it once the server has accepted the no-auth method.
+func socks5ConnectRequest(t *testing.T, host, port string) []byte {
+	t.Helper()
+
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	req := []byte{socks5Version, socks5CmdConnect, 0x00, socks5AtypDomain, byte(len(host))}
+	req = append(req, []byte(host)...)
+	req = append(req, byte(portNum>>8), byte(portNum))
+	return req
+}
+
+// socks5Greet writes the greeting and reads back the method-select reply,
+// the round trip a real client performs before sending its actual request.
+// net.Pipe has no internal buffering, so writes and reads must alternate in
+// lockstep with what the handshake on the other end actually consumes.
+func socks5Greet(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	_, err := conn.Write([]byte{socks5Version, 1, socks5AuthNone})
+	require.NoError(t, err)
+
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	require.Equal(t, byte(socks5AuthNone), reply[1])
+}
+
+func TestServeSocks5_RelaysConnectToDestination(t *testing.T) {
+	dest, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer dest.Close()
+
+	go func() {
+		conn, err := dest.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 1024)
+		n, _ := conn.Read(buf)
+		conn.Write(buf[:n])
+	}()
+
+	client, server := net.Pipe()
+	go serveSocks5(context.Background(), server)
+
+	socks5Greet(t, client)
+
+	destHost, destPort, err := net.SplitHostPort(dest.Addr().String())
+	require.NoError(t, err)
+	_, err = client.Write(socks5ConnectRequest(t, destHost, destPort))
+	require.NoError(t, err)
+
+	reply := make([]byte, 10)
+	_, err = io.ReadFull(client, reply)
+	require.NoError(t, err)
+	assert.Equal(t, byte(socks5Version), reply[0])
+	assert.Equal(t, byte(socks5ReplySucceeded), reply[1])
+
+	_, err = client.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	echoed := make([]byte, 5)
+	_, err = io.ReadFull(client, echoed)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(echoed))
+
+	client.Close()
+}
+
+func TestSocks5Handshake_RejectsUnsupportedVersion(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := socks5Handshake(server)
+		result <- err
+	}()
+
+	// socks5Handshake reads exactly the 2-byte header before checking the
+	// version, so only that much needs to be written here.
+	_, err := client.Write([]byte{0x04, 1})
+	require.NoError(t, err)
+
+	err = <-result
+	assert.Error(t, err)
+}
+
+func TestSocks5Handshake_RejectsUnsupportedCommand(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := socks5Handshake(server)
+		result <- err
+	}()
+
+	socks5Greet(t, client)
+
+	// socks5Handshake reads exactly the 4-byte request header (version, cmd,
+	// rsv, atyp) before checking the command, so only that much is sent:
+	// BIND (0x02) instead of CONNECT.
+	_, err := client.Write([]byte{socks5Version, 0x02, 0x00, socks5AtypIPv4})
+	require.NoError(t, err)
+
+	// The handler writes a command-not-supported reply before returning its
+	// error; drain it so that write doesn't block forever.
+	reply := make([]byte, 10)
+	_, err = io.ReadFull(client, reply)
+	require.NoError(t, err)
+	assert.Equal(t, byte(socks5ReplyCommandNotSupported), reply[1])
+
+	err = <-result
+	assert.Error(t, err)
+}
@@ -0,0 +1,14 @@
+package tunnel
+
+// Target describes a local backend to dial: scheme, host, port, and whether
+// to skip TLS certificate verification. It's the richer sibling of the
+// plain Host/Port pair ClientOptions has always accepted, letting a single
+// tunnel point at an https (or self-signed https) local server instead of
+// only a bare http port. Host empty means "unset"; callers fall back to
+// ClientOptions.Host/Port in that case.
+type Target struct {
+	Scheme             string // "http" or "https"; empty defaults to "http"
+	Host               string
+	Port               int
+	InsecureSkipVerify bool
+}
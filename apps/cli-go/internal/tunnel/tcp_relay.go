@@ -0,0 +1,230 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/coder/websocket"
+
+	"github.com/nkootstra/xpose/internal/protocol"
+)
+
+// tcpRelay manages the relay of a single raw TCP stream through the tunnel to
+// a local TCP server.
+type tcpRelay struct {
+	streamID   string
+	remoteAddr string
+	localConn  net.Conn
+	cancel     context.CancelFunc
+	bytesIn    int64
+	bytesOut   int64
+}
+
+// snapshot returns a TcpStreamInfo reflecting the relay's current byte
+// counts. Callers must hold the owning manager's mutex.
+func (r *tcpRelay) snapshot() TcpStreamInfo {
+	return TcpStreamInfo{
+		StreamID:   r.streamID,
+		RemoteAddr: r.remoteAddr,
+		BytesIn:    r.bytesIn,
+		BytesOut:   r.bytesOut,
+	}
+}
+
+// tcpRelayManager tracks active raw TCP relay connections.
+type tcpRelayManager struct {
+	mu     sync.Mutex
+	relays map[string]*tcpRelay
+	host   string
+	port   int
+	mode   string // "tcp" dials host:port directly; "socks" runs an in-process SOCKS5 server per stream
+}
+
+func newTcpRelayManager(host string, port int, mode string) *tcpRelayManager {
+	return &tcpRelayManager{
+		relays: make(map[string]*tcpRelay),
+		host:   host,
+		port:   port,
+		mode:   mode,
+	}
+}
+
+// handleOpen processes a tcp-open request from the server: connects the
+// stream to its local endpoint and starts relaying data in both directions.
+// In tcp mode that's a direct dial to host:port; in socks mode it's an
+// in-process SOCKS5 server that dials wherever the CONNECT request asks.
+// onStream reports stream lifecycle and byte-count updates for display in
+// the TUI.
+func (mgr *tcpRelayManager) handleOpen(
+	ctx context.Context,
+	tunnelConn *websocket.Conn,
+	msg *protocol.TcpOpenMessage,
+	sendJSON func(ctx context.Context, conn *websocket.Conn, msg any),
+	onStream func(info TcpStreamInfo),
+) {
+	dialCtx, dialCancel := context.WithCancel(ctx)
+
+	var localConn net.Conn
+	if mgr.mode == "socks" {
+		clientSide, serverSide := net.Pipe()
+		go serveSocks5(dialCtx, serverSide)
+		localConn = clientSide
+	} else {
+		localAddr := fmt.Sprintf("%s:%d", mgr.host, mgr.port)
+		var d net.Dialer
+		conn, err := d.DialContext(dialCtx, "tcp", localAddr)
+		if err != nil {
+			dialCancel()
+			sendJSON(ctx, tunnelConn, &protocol.TcpCloseMessage{
+				Type:     "tcp-close",
+				StreamID: msg.StreamID,
+				Reason:   fmt.Sprintf("failed to connect to %s: %s", localAddr, err.Error()),
+			})
+			return
+		}
+		localConn = conn
+	}
+
+	relay := &tcpRelay{
+		streamID:   msg.StreamID,
+		remoteAddr: msg.RemoteAddr,
+		localConn:  localConn,
+		cancel:     dialCancel,
+	}
+
+	mgr.mu.Lock()
+	mgr.relays[msg.StreamID] = relay
+	mgr.mu.Unlock()
+
+	if onStream != nil {
+		onStream(relay.snapshot())
+	}
+
+	go mgr.readLocalAndForward(ctx, tunnelConn, relay, sendJSON, onStream)
+}
+
+// readLocalAndForward reads bytes from the local TCP connection and forwards
+// them through the tunnel as tcp-data + binary frame pairs.
+func (mgr *tcpRelayManager) readLocalAndForward(
+	ctx context.Context,
+	tunnelConn *websocket.Conn,
+	relay *tcpRelay,
+	sendJSON func(ctx context.Context, conn *websocket.Conn, msg any),
+	onStream func(info TcpStreamInfo),
+) {
+	defer mgr.closeRelay(relay.streamID, "local connection closed", sendJSON, tunnelConn, onStream)
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := relay.localConn.Read(buf)
+		if n > 0 {
+			mgr.mu.Lock()
+			relay.bytesOut += int64(n)
+			snapshot := relay.snapshot()
+			mgr.mu.Unlock()
+
+			sendJSON(ctx, tunnelConn, &protocol.TcpDataMessage{
+				Type:     "tcp-data",
+				StreamID: relay.streamID,
+			})
+
+			frame := protocol.EncodeBinaryFrame(relay.streamID, buf[:n])
+			if writeErr := tunnelConn.Write(ctx, websocket.MessageBinary, frame); writeErr != nil {
+				return
+			}
+
+			if onStream != nil {
+				onStream(snapshot)
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handleData forwards a tcp-data binary frame from the tunnel to the local
+// TCP connection identified by streamID.
+func (mgr *tcpRelayManager) handleData(streamID string, body []byte, onStream func(info TcpStreamInfo)) {
+	mgr.mu.Lock()
+	relay, exists := mgr.relays[streamID]
+	if !exists {
+		mgr.mu.Unlock()
+		return
+	}
+	relay.bytesIn += int64(len(body))
+	snapshot := relay.snapshot()
+	mgr.mu.Unlock()
+
+	if _, err := relay.localConn.Write(body); err != nil {
+		mgr.closeRelay(streamID, "write to local connection failed", nil, nil, onStream)
+		return
+	}
+
+	if onStream != nil {
+		onStream(snapshot)
+	}
+}
+
+// handleClose tears down a relay stream in response to a tcp-close message
+// from the server.
+func (mgr *tcpRelayManager) handleClose(msg *protocol.TcpCloseMessage, onStream func(info TcpStreamInfo)) {
+	mgr.closeRelay(msg.StreamID, msg.Reason, nil, nil, onStream)
+}
+
+// closeRelay tears down a relay connection, optionally notifying the tunnel
+// and the TUI of the closure.
+func (mgr *tcpRelayManager) closeRelay(
+	streamID string,
+	reason string,
+	sendJSON func(ctx context.Context, conn *websocket.Conn, msg any),
+	tunnelConn *websocket.Conn,
+	onStream func(info TcpStreamInfo),
+) {
+	mgr.mu.Lock()
+	relay, exists := mgr.relays[streamID]
+	if exists {
+		delete(mgr.relays, streamID)
+	}
+	mgr.mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	relay.cancel()
+	relay.localConn.Close()
+
+	if sendJSON != nil && tunnelConn != nil {
+		sendJSON(context.Background(), tunnelConn, &protocol.TcpCloseMessage{
+			Type:     "tcp-close",
+			StreamID: streamID,
+			Reason:   reason,
+		})
+	}
+
+	if onStream != nil {
+		info := relay.snapshot()
+		info.Closed = true
+		onStream(info)
+	}
+}
+
+// closeAll tears down all active relay connections.
+func (mgr *tcpRelayManager) closeAll() {
+	mgr.mu.Lock()
+	relays := make(map[string]*tcpRelay, len(mgr.relays))
+	for k, v := range mgr.relays {
+		relays[k] = v
+	}
+	mgr.relays = make(map[string]*tcpRelay)
+	mgr.mu.Unlock()
+
+	for _, relay := range relays {
+		relay.cancel()
+		relay.localConn.Close()
+	}
+}
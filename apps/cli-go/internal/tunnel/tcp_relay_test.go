@@ -0,0 +1,158 @@
+package tunnel
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/nkootstra/xpose/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noopSend(ctx context.Context, conn *websocket.Conn, msg any) {}
+
+func TestTcpRelayManager_HandleOpenRegistersRelay(t *testing.T) {
+	localServer, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer localServer.Close()
+
+	go func() {
+		conn, err := localServer.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Keep reading (rather than returning after one Read) so the
+		// connection stays open across the relay-registration assertions
+		// below; closing early would propagate as EOF to
+		// readLocalAndForward and delete the relay before we can assert on it.
+		buf := make([]byte, 1024)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	host, port := parseHostPort("http://" + localServer.Addr().String())
+	mgr := newTcpRelayManager(host, port, "tcp")
+
+	var streamEvents []TcpStreamInfo
+	mgr.handleOpen(context.Background(), nil, &protocol.TcpOpenMessage{
+		Type:       "tcp-open",
+		StreamID:   "stream-1",
+		RemoteAddr: "203.0.113.5:51342",
+	}, noopSend, func(info TcpStreamInfo) {
+		streamEvents = append(streamEvents, info)
+	})
+
+	mgr.mu.Lock()
+	_, exists := mgr.relays["stream-1"]
+	mgr.mu.Unlock()
+	require.True(t, exists, "expected relay to be registered after handleOpen")
+	require.Len(t, streamEvents, 1)
+	assert.Equal(t, "203.0.113.5:51342", streamEvents[0].RemoteAddr)
+
+	mgr.handleData("stream-1", []byte("ping"), func(info TcpStreamInfo) {
+		streamEvents = append(streamEvents, info)
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	mgr.mu.Lock()
+	relay := mgr.relays["stream-1"]
+	mgr.mu.Unlock()
+	require.NotNil(t, relay)
+	assert.Equal(t, int64(len("ping")), relay.bytesIn)
+
+	mgr.closeAll()
+}
+
+func TestTcpRelayManager_HandleOpenFailsOnUnreachableHost(t *testing.T) {
+	mgr := newTcpRelayManager("127.0.0.1", 1, "tcp")
+	var sent []any
+	mgr.handleOpen(context.Background(), nil, &protocol.TcpOpenMessage{
+		Type:     "tcp-open",
+		StreamID: "stream-unreachable",
+	}, func(ctx context.Context, conn *websocket.Conn, msg any) {
+		sent = append(sent, msg)
+	}, nil)
+
+	require.Len(t, sent, 1)
+	closeMsg, ok := sent[0].(*protocol.TcpCloseMessage)
+	require.True(t, ok, "expected a tcp-close message on dial failure")
+	assert.Equal(t, "stream-unreachable", closeMsg.StreamID)
+	assert.NotEmpty(t, closeMsg.Reason)
+}
+
+func TestTcpRelayManager_HandleDataUnknownStreamIsNoop(t *testing.T) {
+	mgr := newTcpRelayManager("127.0.0.1", 1, "tcp")
+	assert.NotPanics(t, func() {
+		mgr.handleData("missing-stream", []byte("data"), nil)
+	})
+}
+
+// TestTcpRelayManager_HandleOpenSocksModeRegistersPipeRelay checks that socks
+// mode registers a relay backed by an in-process net.Pipe instead of dialing
+// host:port directly. The SOCKS5 protocol exchange itself (handshake, dial,
+// byte splicing) is covered directly against serveSocks5 in socks_test.go,
+// without going through a relay whose other end would need a real
+// *websocket.Conn to forward into.
+func TestTcpRelayManager_HandleOpenSocksModeRegistersPipeRelay(t *testing.T) {
+	mgr := newTcpRelayManager("", 0, "socks")
+	mgr.handleOpen(context.Background(), nil, &protocol.TcpOpenMessage{
+		Type:     "tcp-open",
+		StreamID: "stream-socks",
+	}, noopSend, nil)
+
+	mgr.mu.Lock()
+	relay, exists := mgr.relays["stream-socks"]
+	mgr.mu.Unlock()
+	require.True(t, exists, "expected relay to be registered for socks mode")
+	require.NotNil(t, relay.localConn)
+
+	mgr.closeAll()
+}
+
+func TestTcpRelayManager_HandleCloseRemovesRelay(t *testing.T) {
+	localServer, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer localServer.Close()
+
+	go func() {
+		conn, err := localServer.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 1)
+		conn.Read(buf)
+	}()
+
+	host, port := parseHostPort("http://" + localServer.Addr().String())
+	mgr := newTcpRelayManager(host, port, "tcp")
+
+	mgr.handleOpen(context.Background(), nil, &protocol.TcpOpenMessage{
+		Type:     "tcp-open",
+		StreamID: "stream-2",
+	}, noopSend, nil)
+
+	var closedInfo *TcpStreamInfo
+	mgr.handleClose(&protocol.TcpCloseMessage{
+		Type:     "tcp-close",
+		StreamID: "stream-2",
+		Reason:   "peer reset",
+	}, func(info TcpStreamInfo) {
+		closedInfo = &info
+	})
+
+	mgr.mu.Lock()
+	_, exists := mgr.relays["stream-2"]
+	mgr.mu.Unlock()
+	assert.False(t, exists, "expected relay to be removed after handleClose")
+	require.NotNil(t, closedInfo)
+	assert.True(t, closedInfo.Closed)
+}
@@ -2,6 +2,7 @@ package tunnel
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,9 +10,31 @@ import (
 
 	"github.com/coder/websocket"
 
+	"github.com/nkootstra/xpose/internal/metrics"
 	"github.com/nkootstra/xpose/internal/protocol"
 )
 
+// k8sChannelProtocols are the kubectl exec/attach subprotocols that multiplex
+// stdin/stdout/stderr/error/resize over a single WebSocket by prefixing each
+// binary frame with a single-byte channel index (0=stdin, 1=stdout,
+// 2=stderr, 3=error, 4=resize). See k8s.io/apiserver/pkg/util/wsstream.
+const (
+	k8sChannelProtocol       = "channel.k8s.io"
+	k8sChannelProtocolV4     = "v4.channel.k8s.io"
+	k8sChannelProtocolBase64 = "base64.channel.k8s.io"
+)
+
+// isK8sChannelProtocol reports whether subprotocol is one of the kubectl
+// exec/attach channel subprotocols.
+func isK8sChannelProtocol(subprotocol string) bool {
+	switch subprotocol {
+	case k8sChannelProtocol, k8sChannelProtocolV4, k8sChannelProtocolBase64:
+		return true
+	default:
+		return false
+	}
+}
+
 // splitCSV splits a comma-separated header value into trimmed tokens.
 func splitCSV(s string) []string {
 	parts := strings.Split(s, ",")
@@ -27,24 +50,27 @@ func splitCSV(s string) []string {
 // wsRelay manages the relay of a single browser WebSocket connection through
 // the tunnel to a local WebSocket server.
 type wsRelay struct {
-	streamID  string
-	localConn *websocket.Conn
-	cancel    context.CancelFunc
+	streamID    string
+	localConn   *websocket.Conn
+	cancel      context.CancelFunc
+	subprotocol string // negotiated subprotocol, e.g. "v4.channel.k8s.io"
 }
 
 // wsRelayManager tracks active WebSocket relay connections.
 type wsRelayManager struct {
-	mu     sync.Mutex
-	relays map[string]*wsRelay
-	host   string
-	port   int
+	mu      sync.Mutex
+	relays  map[string]*wsRelay
+	host    string
+	port    int
+	metrics *metrics.Registry // optional; nil unless --metrics is set
 }
 
-func newWsRelayManager(host string, port int) *wsRelayManager {
+func newWsRelayManager(host string, port int, reg *metrics.Registry) *wsRelayManager {
 	return &wsRelayManager{
-		relays: make(map[string]*wsRelay),
-		host:   host,
-		port:   port,
+		relays:  make(map[string]*wsRelay),
+		host:    host,
+		port:    port,
+		metrics: reg,
 	}
 }
 
@@ -98,14 +124,18 @@ func (mgr *wsRelayManager) handleUpgrade(
 	localConn.SetReadLimit(32 * 1024 * 1024) // 32MB for WS frames
 
 	relay := &wsRelay{
-		streamID:  msg.StreamID,
-		localConn: localConn,
-		cancel:    dialCancel,
+		streamID:    msg.StreamID,
+		localConn:   localConn,
+		cancel:      dialCancel,
+		subprotocol: localConn.Subprotocol(),
 	}
 
 	mgr.mu.Lock()
 	mgr.relays[msg.StreamID] = relay
 	mgr.mu.Unlock()
+	if mgr.metrics != nil {
+		mgr.metrics.IncActiveWSStreams()
+	}
 
 	// Confirm success
 	sendJSON(ctx, tunnelConn, &protocol.WsUpgradeAckMessage{
@@ -148,6 +178,17 @@ func (mgr *wsRelayManager) readLocalAndForward(
 			frameType = "binary"
 		}
 
+		if isK8sChannelProtocol(relay.subprotocol) {
+			// The kubectl channel protocols multiplex channels over a single
+			// binary-framed connection; never switch to text-mode framing,
+			// and leave the leading channel-index byte untouched.
+			frameType = "binary"
+			if relay.subprotocol == k8sChannelProtocolBase64 && len(data) > 0 {
+				encoded := base64.StdEncoding.EncodeToString(data[1:])
+				data = append([]byte{data[0]}, encoded...)
+			}
+		}
+
 		// Send ws-frame header
 		sendJSON(ctx, tunnelConn, &protocol.WsFrameMessage{
 			Type:      "ws-frame",
@@ -180,6 +221,18 @@ func (mgr *wsRelayManager) handleFrame(msg *protocol.WsFrameMessage, body []byte
 		msgType = websocket.MessageBinary
 	}
 
+	if isK8sChannelProtocol(relay.subprotocol) {
+		msgType = websocket.MessageBinary
+		if relay.subprotocol == k8sChannelProtocolBase64 && len(body) > 0 {
+			decoded, err := base64.StdEncoding.DecodeString(string(body[1:]))
+			if err != nil {
+				mgr.closeRelay(msg.StreamID)
+				return
+			}
+			body = append([]byte{body[0]}, decoded...)
+		}
+	}
+
 	if err := relay.localConn.Write(ctx, msgType, body); err != nil {
 		mgr.closeRelay(msg.StreamID)
 	}
@@ -202,6 +255,9 @@ func (mgr *wsRelayManager) closeRelay(streamID string) {
 	if relay != nil {
 		relay.cancel()
 		relay.localConn.Close(websocket.StatusNormalClosure, "Stream closed")
+		if mgr.metrics != nil {
+			mgr.metrics.DecActiveWSStreams()
+		}
 	}
 }
 
@@ -218,5 +274,8 @@ func (mgr *wsRelayManager) closeAll() {
 	for _, relay := range relays {
 		relay.cancel()
 		relay.localConn.Close(websocket.StatusNormalClosure, "All streams closed")
+		if mgr.metrics != nil {
+			mgr.metrics.DecActiveWSStreams()
+		}
 	}
 }
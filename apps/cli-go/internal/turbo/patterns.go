@@ -73,6 +73,19 @@ func extractExplicitPorts(command string) []int {
 	return ports
 }
 
+// ExtractExplicitPorts is the exported form of extractExplicitPorts, for
+// reuse by other discovery sources (e.g. internal/discovery) that parse a
+// dev-server command line but aren't turbo-specific.
+func ExtractExplicitPorts(command string) []int {
+	return extractExplicitPorts(command)
+}
+
+// InferDefaultPort is the exported form of inferDefaultPort, for reuse by
+// other discovery sources; see ExtractExplicitPorts.
+func InferDefaultPort(command string) *int {
+	return inferDefaultPort(command)
+}
+
 func inferDefaultPort(command string) *int {
 	cmd := strings.TrimSpace(command)
 
@@ -0,0 +1,117 @@
+package turbo
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Target describes a local (or remote) backend to dial: scheme, host, port,
+// and whether to skip TLS certificate verification. It's the richer sibling
+// of the plain int port that extractExplicitPorts/inferDefaultPort produce,
+// for dev setups that forward to something other than a bare http port on
+// localhost.
+type Target struct {
+	Scheme             string // "http" or "https"; empty defaults to "http"
+	Host               string
+	Port               int
+	InsecureSkipVerify bool
+}
+
+const insecureHTTPSScheme = "https+insecure"
+
+// ParseTarget parses a Tailscale-style target string into a Target:
+//
+//	"3030"                    -> http://127.0.0.1:3030
+//	"localhost:3030"          -> http://localhost:3030
+//	"10.2.3.5:3030"           -> http://10.2.3.5:3030
+//	"http://foo:8080"         -> passthrough
+//	"https://foo:8443"        -> passthrough
+//	"https+insecure://foo"    -> https://foo with InsecureSkipVerify=true
+func ParseTarget(raw string) (Target, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Target{}, fmt.Errorf("target must not be empty")
+	}
+
+	if port, err := strconv.Atoi(raw); err == nil {
+		if !isValidPort(port) {
+			return Target{}, fmt.Errorf("invalid target %q: port out of range", raw)
+		}
+		return Target{Scheme: "http", Host: "127.0.0.1", Port: port}, nil
+	}
+
+	scheme := "http"
+	hostport := raw
+	insecure := false
+
+	if idx := strings.Index(raw, "://"); idx >= 0 {
+		scheme = raw[:idx]
+		hostport = raw[idx+len("://"):]
+		if scheme == insecureHTTPSScheme {
+			scheme = "https"
+			insecure = true
+		}
+		if scheme != "http" && scheme != "https" {
+			return Target{}, fmt.Errorf("invalid target %q: unsupported scheme %q", raw, scheme)
+		}
+	}
+
+	host, portStr, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+		portStr = ""
+	}
+	if host == "" {
+		return Target{}, fmt.Errorf("invalid target %q: missing host", raw)
+	}
+
+	port := 0
+	if portStr != "" {
+		port, err = strconv.Atoi(portStr)
+		if err != nil || !isValidPort(port) {
+			return Target{}, fmt.Errorf("invalid target %q: invalid port %q", raw, portStr)
+		}
+	} else if scheme == "https" {
+		port = 443
+	} else {
+		port = 80
+	}
+
+	return Target{Scheme: scheme, Host: host, Port: port, InsecureSkipVerify: insecure}, nil
+}
+
+// extractExplicitTargets is the Target-aware sibling of extractExplicitPorts:
+// it finds the same explicit ports in a task command but reports them as
+// http://127.0.0.1:PORT targets, so callers that need scheme/host richness
+// don't have to special-case the plain-port path.
+func extractExplicitTargets(command string) []Target {
+	ports := extractExplicitPorts(command)
+	targets := make([]Target, len(ports))
+	for i, port := range ports {
+		targets[i] = Target{Scheme: "http", Host: "127.0.0.1", Port: port}
+	}
+	return targets
+}
+
+// ExtractExplicitTargets is the exported form of extractExplicitTargets, for
+// reuse by other discovery sources; see ExtractExplicitPorts.
+func ExtractExplicitTargets(command string) []Target {
+	return extractExplicitTargets(command)
+}
+
+// inferDefaultTarget is the Target-aware sibling of inferDefaultPort.
+func inferDefaultTarget(command string) *Target {
+	port := inferDefaultPort(command)
+	if port == nil {
+		return nil
+	}
+	return &Target{Scheme: "http", Host: "127.0.0.1", Port: *port}
+}
+
+// InferDefaultTarget is the exported form of inferDefaultTarget, for reuse by
+// other discovery sources; see InferDefaultPort.
+func InferDefaultTarget(command string) *Target {
+	return inferDefaultTarget(command)
+}
@@ -0,0 +1,64 @@
+package turbo
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Target
+		wantErr bool
+	}{
+		{"bare port", "3030", Target{Scheme: "http", Host: "127.0.0.1", Port: 3030}, false},
+		{"localhost with port", "localhost:3030", Target{Scheme: "http", Host: "localhost", Port: 3030}, false},
+		{"remote host with port", "10.2.3.5:3030", Target{Scheme: "http", Host: "10.2.3.5", Port: 3030}, false},
+		{"http passthrough", "http://foo:8080", Target{Scheme: "http", Host: "foo", Port: 8080}, false},
+		{"https passthrough", "https://foo:8443", Target{Scheme: "https", Host: "foo", Port: 8443}, false},
+		{"https default port", "https://foo", Target{Scheme: "https", Host: "foo", Port: 443}, false},
+		{"https+insecure", "https+insecure://10.2.3.4:8443", Target{Scheme: "https", Host: "10.2.3.4", Port: 8443, InsecureSkipVerify: true}, false},
+		{"https+insecure default port", "https+insecure://10.2.3.4", Target{Scheme: "https", Host: "10.2.3.4", Port: 443, InsecureSkipVerify: true}, false},
+		{"empty", "", Target{}, true},
+		{"port out of range", "99999", Target{}, true},
+		{"unsupported scheme", "ftp://foo:21", Target{}, true},
+		{"missing host", "http://:8080", Target{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTarget(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseTarget(%q) = %+v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ParseTarget(%q) returned unexpected error: %v", tt.raw, err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractExplicitTargets(t *testing.T) {
+	got := extractExplicitTargets("PORT=3000 next dev")
+	want := []Target{{Scheme: "http", Host: "127.0.0.1", Port: 3000}}
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("extractExplicitTargets(...) = %+v, want %+v", got, want)
+	}
+}
+
+func TestInferDefaultTarget(t *testing.T) {
+	got := inferDefaultTarget("next dev")
+	want := Target{Scheme: "http", Host: "127.0.0.1", Port: 3000}
+	if got == nil || *got != want {
+		t.Errorf("inferDefaultTarget(...) = %v, want %+v", got, want)
+	}
+
+	if got := inferDefaultTarget("echo hi"); got != nil {
+		t.Errorf("inferDefaultTarget(...) = %+v, want nil", got)
+	}
+}